@@ -9,7 +9,11 @@ import (
 
 	"discord-bot/command"
 	"discord-bot/config"
+	"discord-bot/database"
 	"discord-bot/grpc/client"
+	"discord-bot/grpc/events"
+	"discord-bot/grpc/server"
+	"discord-bot/ratelimit"
 	"discord-bot/utils"
 
 	"github.com/bwmarrin/discordgo"
@@ -18,8 +22,12 @@ import (
 
 // Bot encapsulates the bot's state.
 type Bot struct {
-	Session      *discordgo.Session
-	GrpcClient   *client.RegistryClient
+	Session    *discordgo.Session
+	GrpcClient *client.RegistryClient
+	GrpcServer *server.Server
+	Scheduler  *ratelimit.Scheduler // Paces all REST requests of Session
+
+	shutdownHooks []shutdownHook // Run by Stop in registration order
 }
 
 // NewBot creates and initializes a new Bot instance.
@@ -34,6 +42,14 @@ func NewBot() (*Bot, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error creating Discord session: %w", err)
 	}
+	// Queue REST requests by priority and adapt to Discord's rate limits
+	schedulerConfig := ratelimit.DefaultConfig
+	if maxConcurrent := viper.GetInt("bot.rest_max_concurrency"); maxConcurrent > 0 {
+		schedulerConfig.MaxConcurrent = maxConcurrent
+	}
+	scheduler := ratelimit.NewScheduler(dg.Client.Transport, schedulerConfig)
+	dg.Client.Transport = scheduler
+
 	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsGuilds | discordgo.IntentsGuildMembers | discordgo.IntentsGuildMessageReactions | discordgo.IntentsGuildScheduledEvents
 	
 	// 限制State缓存大小以防止内存泄露
@@ -53,9 +69,22 @@ func NewBot() (*Bot, error) {
 	grpcToken := viper.GetString("GRPC_TOKEN")
 	grpcName := viper.GetString("GRPC_CLIENT_NAME")
 
+	// gRPC services exposed by the bot (PostService)
+	grpcServer := server.NewServer()
+
 	var grpcClient *client.RegistryClient
 	if grpcAddr != "" && grpcToken != "" && grpcName != "" {
 		grpcClient = client.NewRegistryClient(grpcAddr, grpcToken, grpcName)
+		grpcClient.SetMaxChunkSize(viper.GetInt("GRPC_MAX_CHUNK_SIZE"))
+		grpcClient.SetTLSConfig(client.TLSConfig{
+			Enabled:    viper.GetBool("GRPC_TLS_ENABLED"),
+			CAFile:     viper.GetString("GRPC_TLS_CA_FILE"),
+			CertFile:   viper.GetString("GRPC_TLS_CERT_FILE"),
+			KeyFile:    viper.GetString("GRPC_TLS_KEY_FILE"),
+			ServerName: viper.GetString("GRPC_TLS_SERVER_NAME"),
+		})
+		grpcServer.RegisterServices(grpcClient)
+		events.InitPublisher(grpcClient)
 		log.Printf("gRPC 客户端已初始化: %s", grpcName)
 	} else {
 		log.Printf("gRPC 配置未完整，跳过 gRPC 客户端初始化")
@@ -64,6 +93,8 @@ func NewBot() (*Bot, error) {
 	return &Bot{
 		Session:    dg,
 		GrpcClient: grpcClient,
+		GrpcServer: grpcServer,
+		Scheduler:  scheduler,
 	}, nil
 }
 
@@ -135,14 +166,26 @@ func (b *Bot) Start(registerHandlers func(*Bot)) error {
 	}
 	log.Println("Finished registering commands.")
 
+	database.FullTextSearchSupported() // Warns once when the binary was built without FTS5
 	startScheduler(b.Session)
 
+	// Start the standalone gRPC listener
+	if listenAddr := viper.GetString("GRPC_LISTEN_ADDRESS"); listenAddr != "" {
+		if err := b.GrpcServer.Listen(listenAddr); err != nil {
+			log.Printf("警告: gRPC 服务监听启动失败: %v", err)
+		}
+	}
+
+	b.registerStatusHandler()
+
 	// Start gRPC client connection
 	if b.GrpcClient != nil {
+		b.registerRemoteEventHandlers()
+		b.watchGatewayState()
 		if err := b.GrpcClient.Connect(); err != nil {
-			log.Printf("警告: gRPC 客户端连接失败: %v", err)
+			log.Printf("警告: gRPC 客户端连接失败，将在后台重试: %v", err)
 		} else {
-			log.Printf("gRPC 客户端已成功连接到网关")
+			log.Printf("gRPC 客户端已连接到网关，等待注册确认")
 		}
 	}
 
@@ -151,20 +194,10 @@ func (b *Bot) Start(registerHandlers func(*Bot)) error {
 	return nil
 }
 
-// Stop gracefully closes the bot's session.
+// Stop gracefully closes the bot's session, cancels running scans, runs the shutdown hooks
+// and closes the gRPC connections, giving up on steps that exceed bot.shutdown_timeout.
 func (b *Bot) Stop() {
-	stopScheduler()
-
-	// Close gRPC client connection
-	if b.GrpcClient != nil {
-		if err := b.GrpcClient.Close(); err != nil {
-			log.Printf("gRPC 客户端关闭错误: %v", err)
-		}
-	}
-
-	if b.Session != nil {
-		b.Session.Close()
-	}
+	b.shutdown()
 	utils.Info("Bot", "Shutdown", "Bot stopped gracefully.")
 	log.Printf("Bot stopped gracefully.")
 }
@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+
+	"discord-bot/database"
+	"discord-bot/grpc/events"
+	eventpb "discord-bot/grpc/proto/gen/event"
+	pb "discord-bot/grpc/proto/gen/registry"
+	"discord-bot/models"
+	"discord-bot/scanner"
+	"discord-bot/utils"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/proto"
+)
+
+// registerRemoteEventHandlers subscribes to gateway events that other services use to drive the bot.
+func (b *Bot) registerRemoteEventHandlers() {
+	if b.GrpcClient == nil {
+		return
+	}
+	b.GrpcClient.Subscribe(events.ScanRequested, func(event *pb.EventMessage) {
+		handleScanRequested(b.Session, event)
+	})
+	b.GrpcClient.Subscribe(events.ExclusionAdded, handleExclusionAdded)
+}
+
+// handleScanRequested starts a scan for the requested guild, or for every configured guild.
+func handleScanRequested(s *discordgo.Session, event *pb.EventMessage) {
+	var req eventpb.ScanRequestedEvent
+	if err := proto.Unmarshal(event.Payload, &req); err != nil {
+		log.Printf("[Events] 无法解析 %s 事件 %s: %v", event.EventType, event.EventId, err)
+		return
+	}
+
+	fullConfig := getScanningConfig()
+	configToScan := fullConfig
+	if req.GuildId != "" {
+		guildConfig, ok := fullConfig[req.GuildId]
+		if !ok {
+			log.Printf("[Events] 扫描请求中的服务器 %s 未配置，忽略", req.GuildId)
+			return
+		}
+		configToScan = models.ScanningConfig{req.GuildId: guildConfig}
+	}
+
+	utils.Info("Events", "ScanRequested", fmt.Sprintf("Remote scan requested by %s (guild: %q, full: %v)", event.PublisherId, req.GuildId, req.FullScan))
+	scanner.StartScanning(s, configToScan, req.FullScan)
+}
+
+// handleExclusionAdded adds a thread to the scanning exclusion list of its guild.
+func handleExclusionAdded(event *pb.EventMessage) {
+	var req eventpb.ExclusionAddedEvent
+	if err := proto.Unmarshal(event.Payload, &req); err != nil {
+		log.Printf("[Events] 无法解析 %s 事件 %s: %v", event.EventType, event.EventId, err)
+		return
+	}
+	if req.GuildId == "" || req.ChannelId == "" || req.ThreadId == "" {
+		log.Printf("[Events] 排除请求 %s 缺少 guild_id/channel_id/thread_id，忽略", event.EventId)
+		return
+	}
+
+	var guildConfig models.GuildConfig
+	if err := viper.UnmarshalKey("scanning_config."+req.GuildId, &guildConfig); err != nil || guildConfig.DBPath == "" {
+		log.Printf("[Events] 服务器 %s 未配置扫描数据库，忽略排除请求", req.GuildId)
+		return
+	}
+
+	db, err := database.InitDB(guildConfig.DBPath)
+	if err != nil {
+		log.Printf("[Events] 打开服务器 %s 的数据库失败: %v", req.GuildId, err)
+		return
+	}
+	defer db.Close()
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "Remote request"
+	}
+	if err := database.AddThreadToExclusionList(db, req.GuildId, req.ChannelId, req.ThreadId, reason); err != nil {
+		log.Printf("[Events] 将帖子 %s 加入排除列表失败: %v", req.ThreadId, err)
+		return
+	}
+	utils.Info("Events", "ExclusionAdded", fmt.Sprintf("Thread %s in channel %s excluded from scanning: %s", req.ThreadId, req.ChannelId, reason))
+}
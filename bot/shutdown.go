@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"discord-bot/scanner"
+
+	"github.com/spf13/viper"
+)
+
+// defaultShutdownTimeout bounds Stop when bot.shutdown_timeout is not configured.
+const defaultShutdownTimeout = 30 * time.Second
+
+// shutdownHook releases a component when the bot stops.
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// OnShutdown registers fn to run when the bot stops, after gateway events stopped arriving
+// and running scans were cancelled, but before the gRPC connections are closed. Hooks run
+// in registration order and should return when ctx expires.
+func (b *Bot) OnShutdown(name string, fn func(ctx context.Context) error) {
+	b.shutdownHooks = append(b.shutdownHooks, shutdownHook{name: name, fn: fn})
+}
+
+// shutdownTimeout reads bot.shutdown_timeout, e.g. "45s".
+func shutdownTimeout() time.Duration {
+	if timeout := viper.GetDuration("bot.shutdown_timeout"); timeout > 0 {
+		return timeout
+	}
+	return defaultShutdownTimeout
+}
+
+// shutdown stops the bot's components in dependency order within the configured deadline.
+// Steps that are still running when the deadline passes are abandoned so the process can exit.
+func (b *Bot) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout())
+	defer cancel()
+
+	stopScheduler()
+
+	// Stop receiving gateway events. The REST client keeps working for the hooks below.
+	if b.Session != nil {
+		b.Session.Close()
+	}
+
+	if err := scanner.Stop(ctx); err != nil {
+		log.Printf("停止扫描时出错: %v", err)
+	}
+
+	for _, hook := range b.shutdownHooks {
+		runShutdownHook(ctx, hook)
+	}
+
+	// Close gRPC client connection
+	if b.GrpcClient != nil {
+		if err := b.GrpcClient.Close(); err != nil {
+			log.Printf("gRPC 客户端关闭错误: %v", err)
+		}
+	}
+
+	// Stop the gRPC services
+	if b.GrpcServer != nil {
+		b.GrpcServer.Stop()
+	}
+}
+
+// runShutdownHook runs a hook and waits until it returns or ctx expires.
+func runShutdownHook(ctx context.Context, hook shutdownHook) {
+	if ctx.Err() != nil {
+		log.Printf("Shutdown deadline passed, skipping %s", hook.name)
+		return
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- hook.fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			log.Printf("Error shutting down %s: %v", hook.name, err)
+			return
+		}
+		log.Printf("Shut down %s in %v", hook.name, time.Since(start))
+	case <-ctx.Done():
+		log.Printf("Shutdown deadline passed while waiting for %s", hook.name)
+	}
+}
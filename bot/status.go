@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"discord-bot/database/message/batch"
+	"discord-bot/grpc/client"
+	"discord-bot/ratelimit"
+	"discord-bot/utils"
+)
+
+// StatusReport is the payload served on /status.
+type StatusReport struct {
+	StartedAt time.Time                `json:"started_at"`
+	Gateway   *client.Status           `json:"gateway,omitempty"`
+	Writers   map[string]batch.Metrics `json:"writers,omitempty"` // Message write buffers by handler
+	RateLimit *ratelimit.Stats         `json:"rate_limit,omitempty"`
+
+	Dedup map[string]utils.ExpiringSetStats `json:"dedup,omitempty"` // Duplicate event filters by handler and event type
+}
+
+var registerStatusOnce sync.Once
+
+// registerStatusHandler exposes the bot's health on /status of the default HTTP mux,
+// which is served together with pprof.
+func (b *Bot) registerStatusHandler() {
+	startedAt := time.Now()
+	registerStatusOnce.Do(func() {
+		http.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+			report := StatusReport{
+				StartedAt: startedAt,
+				Writers:   batch.AllMetrics(),
+				Dedup:     utils.AllExpiringSetStats(),
+			}
+			if b.Scheduler != nil {
+				stats := b.Scheduler.Stats()
+				report.RateLimit = &stats
+			}
+			if b.GrpcClient != nil {
+				status := b.GrpcClient.Status()
+				report.Gateway = &status
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			if report.Gateway != nil && report.Gateway.State != client.StateRegistered {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			json.NewEncoder(w).Encode(report)
+		})
+	})
+}
+
+// watchGatewayState reports gateway connection problems to the log channel.
+func (b *Bot) watchGatewayState() {
+	if b.GrpcClient == nil {
+		return
+	}
+	b.GrpcClient.OnStateChange(func(from, to client.ConnectionState) {
+		switch to {
+		case client.StateDegraded:
+			utils.Warn("gRPC", "Gateway", "No heartbeat from the gateway, connection degraded.")
+		case client.StateConnecting:
+			if from == client.StateRegistered || from == client.StateDegraded {
+				utils.Warn("gRPC", "Gateway", fmt.Sprintf("Connection lost, reconnecting: %s", b.GrpcClient.Status().LastError))
+			}
+		case client.StateRegistered:
+			if from == client.StateDegraded || from == client.StateConnecting {
+				utils.Info("gRPC", "Gateway", "Connected to the gateway.")
+			}
+		}
+	})
+}
@@ -9,46 +9,83 @@ type ScanCommand struct{}
 func (c *ScanCommand) Definition() *discordgo.ApplicationCommand {
 	return &discordgo.ApplicationCommand{
 		Name:        "scan",
-		Description: "Manually trigger a scan",
+		Description: "Manually trigger, follow or cancel a scan",
 		Options: []*discordgo.ApplicationCommandOption{
 			{
-				Name:        "type",
-				Description: "The type of scan to perform",
-				Type:        discordgo.ApplicationCommandOptionString,
-				Required:    true,
-				Choices: []*discordgo.ApplicationCommandOptionChoice{
+				Name:        "start",
+				Description: "Start a scan and follow its progress",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
 					{
-						Name:  "Global Scan",
-						Value: "global",
+						Name:        "type",
+						Description: "The type of scan to perform",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{
+								Name:  "Global Scan",
+								Value: "global",
+							},
+							{
+								Name:  "Guild Scan",
+								Value: "guild",
+							},
+						},
 					},
 					{
-						Name:  "Guild Scan",
-						Value: "guild",
+						Name:        "scan_mode",
+						Description: "The mode of scan to perform",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{
+								Name:  "Full Scan",
+								Value: "full_scan",
+							},
+							{
+								Name:  "Active Thread Scan",
+								Value: "active_thread_scan",
+							},
+						},
+					},
+					{
+						Name:         "guild_id",
+						Description:  "The guild to scan (required for guild scan)",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Required:     false,
+						Autocomplete: true,
 					},
-				},
-			},
-			{
-				Name:        "scan_mode",
-				Description: "The mode of scan to perform",
-				Type:        discordgo.ApplicationCommandOptionString,
-				Required:    true,
-				Choices: []*discordgo.ApplicationCommandOptionChoice{
 					{
-						Name:  "Full Scan",
-						Value: "full_scan",
+						Name:         "channel",
+						Description:  "Only scan this forum channel (overrides type and guild_id)",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Required:     false,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildForum},
 					},
 					{
-						Name:  "Active Thread Scan",
-						Value: "active_thread_scan",
+						Name:         "category",
+						Description:  "Only scan this configured category (overrides type and guild_id)",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Required:     false,
+						Autocomplete: true,
+					},
+					{
+						Name:        "dry_run",
+						Description: "Only report which post statuses the scan would change",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Required:    false,
 					},
 				},
 			},
 			{
-				Name:         "guild_id",
-				Description:  "The guild to scan (required for guild scan)",
-				Type:         discordgo.ApplicationCommandOptionString,
-				Required:     false,
-				Autocomplete: true,
+				Name:        "status",
+				Description: "Show the progress of the running scan, or the result of the last one",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "cancel",
+				Description: "Cancel the running scan",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
 			},
 		},
 	}
@@ -67,3 +104,203 @@ func (c *PingCommand) Definition() *discordgo.ApplicationCommand {
 
 // RecentPostsCommand defines the structure for the /recent_posts command.
 type RecentPostsCommand struct{}
+
+// Definition returns the application command definition.
+func (c *RecentPostsCommand) Definition() *discordgo.ApplicationCommand {
+	minReactions := 0.0
+	return &discordgo.ApplicationCommand{
+		Name:        "recent_posts",
+		Description: "List the newest active posts",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:         "channel",
+				Description:  "The forum channel or category to list posts from",
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Required:     true,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildForum, discordgo.ChannelTypeGuildCategory},
+			},
+			{
+				Name:         "tag",
+				Description:  "Only show posts with this tag",
+				Type:         discordgo.ApplicationCommandOptionString,
+				Required:     false,
+				Autocomplete: true,
+			},
+			{
+				Name:        "author",
+				Description: "Only show posts by this user",
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Required:    false,
+			},
+			{
+				Name:        "min_reactions",
+				Description: "Only show posts with at least this many reactions",
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Required:    false,
+				MinValue:    &minReactions,
+			},
+		},
+	}
+}
+
+// SearchCommand defines the structure for the /search command.
+type SearchCommand struct{}
+
+// Definition returns the application command definition.
+func (c *SearchCommand) Definition() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "search",
+		Description: "Search posts by title, content and tags",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "query",
+				Description: "The keywords to search for",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Required:    true,
+			},
+			{
+				Name:         "channel",
+				Description:  "Only search this forum channel or category",
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Required:     false,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildForum, discordgo.ChannelTypeGuildCategory},
+			},
+			{
+				Name:        "author",
+				Description: "Only search posts by this user",
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Required:    false,
+			},
+		},
+	}
+}
+
+// StatsCommand defines the structure for the /stats command family.
+type StatsCommand struct{}
+
+// Definition returns the application command definition.
+func (c *StatsCommand) Definition() *discordgo.ApplicationCommand {
+	minDays, maxDays := 1.0, 90.0
+	minLimit, maxLimit := 1.0, 25.0
+
+	daysOption := &discordgo.ApplicationCommandOption{
+		Name:        "days",
+		Description: "How many days to look back (default 7)",
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Required:    false,
+		MinValue:    &minDays,
+		MaxValue:    maxDays,
+	}
+	limitOption := &discordgo.ApplicationCommandOption{
+		Name:        "limit",
+		Description: "How many entries to show (default 10)",
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Required:    false,
+		MinValue:    &minLimit,
+		MaxValue:    maxLimit,
+	}
+	channelOption := &discordgo.ApplicationCommandOption{
+		Name:        "channel",
+		Description: "Only count messages in this channel",
+		Type:        discordgo.ApplicationCommandOptionChannel,
+		Required:    false,
+	}
+
+	return &discordgo.ApplicationCommand{
+		Name:        "stats",
+		Description: "Show message statistics for this server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "channels",
+				Description: "Show the most active channels",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options:     []*discordgo.ApplicationCommandOption{daysOption, limitOption},
+			},
+			{
+				Name:        "users",
+				Description: "Show the most active users",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options:     []*discordgo.ApplicationCommandOption{daysOption, limitOption, channelOption},
+			},
+			{
+				Name:        "activity",
+				Description: "Show a histogram of message activity",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "granularity",
+						Description: "Group messages by hour of day or by day",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{
+								Name:  "Hour of Day",
+								Value: "hourly",
+							},
+							{
+								Name:  "Day",
+								Value: "daily",
+							},
+						},
+					},
+					daysOption,
+					channelOption,
+					{
+						Name:        "user",
+						Description: "Only count messages from this user",
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Required:    false,
+					},
+				},
+			},
+		},
+	}
+}
+
+// HistoryCommand defines the structure for the "history" message context-menu command.
+type HistoryCommand struct{}
+
+// Definition returns the application command definition.
+func (c *HistoryCommand) Definition() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name: "history",
+		Type: discordgo.MessageApplicationCommand,
+	}
+}
+
+// DeletedCommand defines the structure for the /deleted command.
+type DeletedCommand struct{}
+
+// Definition returns the application command definition.
+func (c *DeletedCommand) Definition() *discordgo.ApplicationCommand {
+	minDays, maxDays := 1.0, 90.0
+	minLimit, maxLimit := 1.0, 10.0
+	return &discordgo.ApplicationCommand{
+		Name:        "deleted",
+		Description: "Show recently deleted messages in a channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "channel",
+				Description: "The channel to show deleted messages from",
+				Type:        discordgo.ApplicationCommandOptionChannel,
+				Required:    true,
+			},
+			{
+				Name:        "limit",
+				Description: "How many messages to show (default 10)",
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Required:    false,
+				MinValue:    &minLimit,
+				MaxValue:    maxLimit,
+			},
+			{
+				Name:        "days",
+				Description: "How many days to look back (default 7)",
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Required:    false,
+				MinValue:    &minDays,
+				MaxValue:    maxDays,
+			},
+		},
+	}
+}
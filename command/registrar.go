@@ -11,6 +11,11 @@ type Command interface {
 var AllCommands = []Command{
 	&ScanCommand{},
 	&PingCommand{},
+	&RecentPostsCommand{},
+	&SearchCommand{},
+	&StatsCommand{},
+	&HistoryCommand{},
+	&DeletedCommand{},
 }
 
 // GetCommandDefinitions returns a slice of all command definitions.
@@ -38,6 +38,12 @@ func InitDB(dbPath string) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to create exclusions table: %w", err)
 	}
 
+	// Create the scan checkpoint and history tables if they don't exist
+	if err := createScanTables(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create scan tables: %w", err)
+	}
+
 	log.Println("Successfully connected to the database at", dbPath)
 	return db, nil
 }
@@ -91,7 +97,7 @@ func InsertPost(db *sql.DB, post models.Post, tableName string) error {
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(
+	result, err := stmt.Exec(
 		post.ThreadID,
 		post.ChannelID,
 		post.Title,
@@ -109,6 +115,13 @@ func InsertPost(db *sql.DB, post models.Post, tableName string) error {
 		return fmt.Errorf("failed to execute statement for saving post %s: %w", post.ThreadID, err)
 	}
 
+	// Only index the post if it was actually inserted.
+	if affected, err := result.RowsAffected(); err == nil && affected > 0 {
+		if err := indexPost(db, post); err != nil {
+			log.Printf("Failed to update search index: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -199,19 +212,6 @@ func UpdatePostStatus(db *sql.DB, tableName, threadID, status string) error {
 	return nil
 }
 
-// ArchiveAllPosts marks all posts in a table as archived
-func ArchiveAllPosts(db *sql.DB, tableName string) error {
-	query := fmt.Sprintf(`UPDATE %s SET status = 'archived' WHERE 1=1`, tableName)
-	
-	_, err := db.Exec(query)
-	if err != nil {
-		return fmt.Errorf("failed to archive all posts in table %s: %w", tableName, err)
-	}
-	
-	log.Printf("All posts in table %s marked as archived", tableName)
-	return nil
-}
-
 // UpsertActivePost inserts a new post or updates existing post with latest data and marks it as active
 func UpsertActivePost(db *sql.DB, post models.Post, tableName string) error {
 	query := fmt.Sprintf(`
@@ -244,5 +244,9 @@ func UpsertActivePost(db *sql.DB, post models.Post, tableName string) error {
 		return fmt.Errorf("failed to execute statement for upserting active post %s: %w", post.ThreadID, err)
 	}
 
+	if err := indexPost(db, post); err != nil {
+		log.Printf("Failed to update search index: %v", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,372 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	database "discord-bot/database/message"
+	"discord-bot/models"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	queueSize       = 256
+	downloadTimeout = 60 * time.Second
+)
+
+var (
+	// ErrTooLarge is returned when an attachment exceeds the configured maximum file size.
+	ErrTooLarge = errors.New("attachment exceeds the maximum file size")
+	// ErrTypeNotAllowed is returned when the content type of an attachment is not allowed.
+	ErrTypeNotAllowed = errors.New("attachment content type is not allowed")
+	// ErrQuotaExceeded is returned when storing an attachment would exceed the guild's quota.
+	ErrQuotaExceeded = errors.New("attachment archive quota exceeded")
+)
+
+// Source describes an attachment to archive, as reported by Discord.
+type Source struct {
+	URL         string
+	Filename    string
+	ContentType string // May be empty, the response header is used then
+	Size        int64  // May be 0 when unknown
+}
+
+type job struct {
+	messageID int64
+	sources   []Source
+}
+
+// Archiver copies message attachments into a content-addressed store before their CDN URLs
+// expire. Files are stored once per SHA-256 under <dir>/objects and indexed in
+// <dir>/attachments.db, which is kept apart from the rotating plus databases so every
+// period can reach it.
+type Archiver struct {
+	guildID string
+	config  models.AttachmentArchiveConfig
+	db      *sql.DB
+	client  *http.Client
+
+	storeMutex sync.Mutex // Serialises quota checks and writes to the store
+	queue      chan job
+	done       chan struct{}
+	wg         sync.WaitGroup
+	closeOnce  sync.Once
+}
+
+// New opens (creating if needed) the attachment archive of a guild and starts its download worker.
+func New(guildID string, config models.AttachmentArchiveConfig) (*Archiver, error) {
+	if config.Dir == "" {
+		return nil, fmt.Errorf("attachment archive of guild %s has no dir configured", guildID)
+	}
+	if err := os.MkdirAll(filepath.Join(config.Dir, "objects"), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create attachment archive directory %s: %w", config.Dir, err)
+	}
+
+	dbPath := filepath.Join(config.Dir, "attachments.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open attachment index at %s: %w", dbPath, err)
+	}
+	if _, err := db.Exec("PRAGMA journal_mode=WAL;"); err != nil {
+		log.Printf("Warning: failed to enable WAL mode for %s: %v", dbPath, err)
+	}
+	if err := createAttachmentsTable(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	a := &Archiver{
+		guildID: guildID,
+		config:  config,
+		db:      db,
+		client:  &http.Client{Timeout: downloadTimeout},
+		queue:   make(chan job, queueSize),
+		done:    make(chan struct{}),
+	}
+	a.wg.Add(1)
+	go a.worker()
+
+	log.Printf("Attachment archive initialized for guild %s at %s", guildID, config.Dir)
+	return a, nil
+}
+
+// createAttachmentsTable creates the attachments index if it doesn't exist.
+func createAttachmentsTable(db *sql.DB) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS attachments (
+        message_id INTEGER NOT NULL,
+        url TEXT NOT NULL,
+        hash TEXT NOT NULL,
+        filename TEXT NOT NULL DEFAULT '',
+        size INTEGER NOT NULL,
+        content_type TEXT NOT NULL DEFAULT '',
+        archived_at INTEGER NOT NULL,
+        PRIMARY KEY (message_id, url)
+    );`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create attachments table: %w", err)
+	}
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_attachments_hash ON attachments(hash);"); err != nil {
+		log.Printf("Warning: failed to create index: %v", err)
+	}
+	return nil
+}
+
+// SetHTTPClient replaces the client used for downloads, e.g. to point at a local stand-in for the CDN.
+func (a *Archiver) SetHTTPClient(client *http.Client) {
+	a.client = client
+}
+
+// Enqueue schedules the attachments of a message for archiving. Attachments whose reported
+// size or type is already known to be rejected are skipped. It never blocks; when the queue
+// is full the attachments are dropped and false is returned.
+func (a *Archiver) Enqueue(messageID int64, sources []Source) bool {
+	accepted := make([]Source, 0, len(sources))
+	for _, source := range sources {
+		if a.check(source.Size, source.ContentType) == nil {
+			accepted = append(accepted, source)
+		}
+	}
+	if len(accepted) == 0 {
+		return true
+	}
+
+	select {
+	case <-a.done:
+		return false
+	default:
+	}
+	select {
+	case a.queue <- job{messageID: messageID, sources: accepted}:
+		return true
+	default:
+		log.Printf("Attachment archive queue of guild %s is full, dropping %d attachments of message %d", a.guildID, len(accepted), messageID)
+		return false
+	}
+}
+
+// worker archives queued attachments one at a time until the archiver is closed.
+func (a *Archiver) worker() {
+	defer a.wg.Done()
+	for {
+		select {
+		case <-a.done:
+			return
+		case j := <-a.queue:
+			for _, source := range j.sources {
+				if err := a.Archive(j.messageID, source); err != nil {
+					log.Printf("Failed to archive attachment %s of message %d: %v", source.Filename, j.messageID, err)
+				}
+			}
+		}
+	}
+}
+
+// Archive downloads a single attachment into the store and records it. Content already in the
+// store is not downloaded to disk twice and does not count against the quota again.
+func (a *Archiver) Archive(messageID int64, source Source) error {
+	var exists int
+	err := a.db.QueryRow("SELECT 1 FROM attachments WHERE message_id = ? AND url = ?", messageID, source.URL).Scan(&exists)
+	if err == nil {
+		return nil // Already archived, e.g. when an edit keeps the attachment
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("failed to look up attachment: %w", err)
+	}
+	if err := a.check(source.Size, source.ContentType); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download attachment: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download attachment: unexpected status %s", resp.Status)
+	}
+
+	contentType := source.ContentType
+	if contentType == "" {
+		contentType = resp.Header.Get("Content-Type")
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	if err := a.check(resp.ContentLength, contentType); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(a.config.Dir, "objects"), "download-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed into the store
+
+	hasher := sha256.New()
+	body := io.Reader(resp.Body)
+	if a.config.MaxFileSize > 0 {
+		body = io.LimitReader(resp.Body, a.config.MaxFileSize+1)
+	}
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save attachment: %w", err)
+	}
+	if a.config.MaxFileSize > 0 && size > a.config.MaxFileSize {
+		return ErrTooLarge
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	a.storeMutex.Lock()
+	defer a.storeMutex.Unlock()
+
+	objectPath := a.objectPath(hash)
+	if _, err := os.Stat(objectPath); os.IsNotExist(err) {
+		if a.config.QuotaBytes > 0 {
+			used, err := a.UsedBytes()
+			if err != nil {
+				return err
+			}
+			if used+size > a.config.QuotaBytes {
+				return ErrQuotaExceeded
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(objectPath), 0755); err != nil {
+			return fmt.Errorf("failed to create object directory: %w", err)
+		}
+		if err := os.Rename(tmp.Name(), objectPath); err != nil {
+			return fmt.Errorf("failed to move attachment into the store: %w", err)
+		}
+	}
+
+	_, err = a.db.Exec(`INSERT OR IGNORE INTO attachments (message_id, url, hash, filename, size, content_type, archived_at)
+	                    VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		messageID, source.URL, hash, source.Filename, size, contentType, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record attachment: %w", err)
+	}
+	return nil
+}
+
+// check applies the size and type limits. Unknown values (0 or "") pass.
+func (a *Archiver) check(size int64, contentType string) error {
+	if a.config.MaxFileSize > 0 && size > a.config.MaxFileSize {
+		return ErrTooLarge
+	}
+	if contentType == "" || len(a.config.AllowedTypes) == 0 {
+		return nil
+	}
+	for _, allowed := range a.config.AllowedTypes {
+		if strings.HasPrefix(contentType, allowed) {
+			return nil
+		}
+	}
+	return ErrTypeNotAllowed
+}
+
+// UsedBytes returns the total size of the files in the store. Content shared by several
+// messages is counted once.
+func (a *Archiver) UsedBytes() (int64, error) {
+	var used int64
+	err := a.db.QueryRow("SELECT COALESCE(SUM(size), 0) FROM (SELECT MAX(size) AS size FROM attachments GROUP BY hash)").Scan(&used)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute archive usage: %w", err)
+	}
+	return used, nil
+}
+
+// Attachments returns the archived attachments of a message.
+func (a *Archiver) Attachments(messageID int64) ([]models.ArchivedAttachment, error) {
+	rows, err := a.db.Query(`SELECT message_id, url, hash, filename, size, content_type, archived_at
+	                         FROM attachments WHERE message_id = ? ORDER BY archived_at, rowid`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments of message %d: %w", messageID, err)
+	}
+	defer rows.Close()
+
+	var attachments []models.ArchivedAttachment
+	for rows.Next() {
+		var attachment models.ArchivedAttachment
+		if err := rows.Scan(&attachment.MessageID, &attachment.URL, &attachment.Hash, &attachment.Filename,
+			&attachment.Size, &attachment.ContentType, &attachment.ArchivedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// Get returns the first recorded attachment with the given content hash, or nil, nil if none.
+func (a *Archiver) Get(hash string) (*models.ArchivedAttachment, error) {
+	var attachment models.ArchivedAttachment
+	err := a.db.QueryRow(`SELECT message_id, url, hash, filename, size, content_type, archived_at
+	                      FROM attachments WHERE hash = ? ORDER BY archived_at LIMIT 1`, hash).Scan(
+		&attachment.MessageID, &attachment.URL, &attachment.Hash, &attachment.Filename,
+		&attachment.Size, &attachment.ContentType, &attachment.ArchivedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query attachment %s: %w", hash, err)
+	}
+	return &attachment, nil
+}
+
+// Open opens the stored file with the given content hash.
+func (a *Archiver) Open(hash string) (*os.File, error) {
+	if !validHash(hash) {
+		return nil, fmt.Errorf("invalid attachment hash %q", hash)
+	}
+	return os.Open(a.objectPath(hash))
+}
+
+// objectPath returns where content with the given hash is stored, fanned out by its first byte.
+func (a *Archiver) objectPath(hash string) string {
+	return filepath.Join(a.config.Dir, "objects", hash[:2], hash)
+}
+
+// validHash reports whether s is a hex encoded SHA-256, which keeps lookups inside the store.
+func validHash(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// Close stops the download worker and closes the index. Attachments still queued are dropped.
+func (a *Archiver) Close() error {
+	var err error
+	a.closeOnce.Do(func() {
+		close(a.done)
+		a.wg.Wait()
+		if dropped := len(a.queue); dropped > 0 {
+			log.Printf("Attachment archive of guild %s closed with %d messages still queued", a.guildID, dropped)
+		}
+		err = database.CheckpointAndClose(a.db)
+	})
+	return err
+}
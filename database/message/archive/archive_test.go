@@ -0,0 +1,281 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"discord-bot/models"
+)
+
+// cdnFile is a file served by fakeCDN.
+type cdnFile struct {
+	body        []byte
+	contentType string
+	chunked     bool // Stream the body without a Content-Length
+}
+
+// fakeCDN stands in for the Discord CDN and counts the downloads of each path.
+type fakeCDN struct {
+	*httptest.Server
+	files map[string]cdnFile
+
+	mutex sync.Mutex
+	hits  map[string]int
+}
+
+func newFakeCDN(t *testing.T, files map[string]cdnFile) *fakeCDN {
+	t.Helper()
+	cdn := &fakeCDN{files: files, hits: make(map[string]int)}
+	cdn.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cdn.mutex.Lock()
+		cdn.hits[r.URL.Path]++
+		cdn.mutex.Unlock()
+
+		file, ok := cdn.files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", file.contentType)
+		if !file.chunked {
+			w.Write(file.body)
+			return
+		}
+		// Flushing before the body is complete forces chunked encoding, so the client
+		// sees no Content-Length and only the LimitReader can stop an oversized body.
+		half := len(file.body) / 2
+		w.Write(file.body[:half])
+		w.(http.Flusher).Flush()
+		w.Write(file.body[half:])
+	}))
+	t.Cleanup(cdn.Close)
+	return cdn
+}
+
+func (c *fakeCDN) downloads(path string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.hits[path]
+}
+
+// newTestArchiver opens an archiver in a temporary directory that downloads from cdn.
+func newTestArchiver(t *testing.T, cdn *fakeCDN, config models.AttachmentArchiveConfig) *Archiver {
+	t.Helper()
+	config.Dir = t.TempDir()
+	a, err := New("1", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.SetHTTPClient(cdn.Client())
+	t.Cleanup(func() { a.Close() })
+	return a
+}
+
+// storedObjects returns the files in the store, including leftover temporary downloads.
+func storedObjects(t *testing.T, a *Archiver) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(filepath.Join(a.config.Dir, "objects"), func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, d.Name())
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestArchiveMaxFileSize(t *testing.T) {
+	const maxFileSize = 1024
+	cdn := newFakeCDN(t, map[string]cdnFile{
+		"/fits.bin":     {body: bytes.Repeat([]byte("a"), maxFileSize), contentType: "application/octet-stream"},
+		"/large.bin":    {body: bytes.Repeat([]byte("b"), maxFileSize+1), contentType: "application/octet-stream"},
+		"/streamed.bin": {body: bytes.Repeat([]byte("c"), 4*maxFileSize), contentType: "application/octet-stream", chunked: true},
+	})
+
+	tests := []struct {
+		name          string
+		path          string
+		reportedSize  int64
+		wantErr       error
+		wantDownloads int
+	}{
+		{"at the limit", "/fits.bin", maxFileSize, nil, 1},
+		{"reported size over the limit", "/large.bin", maxFileSize + 1, ErrTooLarge, 0},
+		{"Content-Length over a reported size that lies", "/large.bin", 10, ErrTooLarge, 1},
+		{"streamed body over the limit", "/streamed.bin", 0, ErrTooLarge, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestArchiver(t, cdn, models.AttachmentArchiveConfig{MaxFileSize: maxFileSize})
+			before := cdn.downloads(tt.path)
+
+			err := a.Archive(1, Source{URL: cdn.URL + tt.path, Filename: "file.bin", Size: tt.reportedSize})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if downloads := cdn.downloads(tt.path) - before; downloads != tt.wantDownloads {
+				t.Fatalf("downloaded %d times, want %d", downloads, tt.wantDownloads)
+			}
+
+			attachments, err := a.Attachments(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := storedObjects(t, a)
+			if tt.wantErr != nil && (len(attachments) != 0 || len(stored) != 0) {
+				t.Fatalf("rejected attachment left %d records and files %v", len(attachments), stored)
+			}
+			if tt.wantErr == nil && (len(attachments) != 1 || attachments[0].Size != maxFileSize || len(stored) != 1) {
+				t.Fatalf("got records %v and files %v", attachments, stored)
+			}
+		})
+	}
+}
+
+func TestArchiveAllowedTypes(t *testing.T) {
+	cdn := newFakeCDN(t, map[string]cdnFile{
+		"/image.png": {body: []byte("png"), contentType: "image/png; charset=binary"},
+		"/file.zip":  {body: []byte("zip"), contentType: "application/zip"},
+	})
+
+	tests := []struct {
+		name         string
+		path         string
+		reportedType string
+		wantErr      error
+		wantType     string
+	}{
+		{"allowed response type", "/image.png", "", nil, "image/png"},
+		{"rejected response type", "/file.zip", "", ErrTypeNotAllowed, ""},
+		{"reported type wins over the response", "/file.zip", "image/gif", nil, "image/gif"},
+		{"rejected reported type", "/image.png", "video/mp4", ErrTypeNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestArchiver(t, cdn, models.AttachmentArchiveConfig{AllowedTypes: []string{"image/"}})
+
+			err := a.Archive(1, Source{URL: cdn.URL + tt.path, ContentType: tt.reportedType})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			attachments, err := a.Attachments(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != nil {
+				if len(attachments) != 0 {
+					t.Fatalf("rejected attachment was recorded: %v", attachments)
+				}
+				return
+			}
+			if len(attachments) != 1 || attachments[0].ContentType != tt.wantType {
+				t.Fatalf("got records %v, want content type %q", attachments, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestArchiveQuota(t *testing.T) {
+	cdn := newFakeCDN(t, map[string]cdnFile{
+		"/first.bin":  {body: bytes.Repeat([]byte("a"), 60)},
+		"/copy.bin":   {body: bytes.Repeat([]byte("a"), 60)},
+		"/second.bin": {body: bytes.Repeat([]byte("b"), 60)},
+		"/small.bin":  {body: bytes.Repeat([]byte("c"), 40)},
+	})
+	a := newTestArchiver(t, cdn, models.AttachmentArchiveConfig{QuotaBytes: 100})
+
+	steps := []struct {
+		path     string
+		wantErr  error
+		wantUsed int64
+	}{
+		{"/first.bin", nil, 60},
+		{"/second.bin", ErrQuotaExceeded, 60},
+		{"/copy.bin", nil, 60}, // Content already in the store does not count again
+		{"/small.bin", nil, 100},
+	}
+	for i, step := range steps {
+		err := a.Archive(int64(i), Source{URL: cdn.URL + step.path})
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: got error %v, want %v", step.path, err, step.wantErr)
+		}
+		used, err := a.UsedBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if used != step.wantUsed {
+			t.Fatalf("%s: used %d bytes, want %d", step.path, used, step.wantUsed)
+		}
+	}
+	if stored := storedObjects(t, a); len(stored) != 2 {
+		t.Fatalf("store holds %v, want 2 files", stored)
+	}
+}
+
+func TestArchiveDeduplicatesContent(t *testing.T) {
+	content := []byte("the same attachment under two URLs")
+	cdn := newFakeCDN(t, map[string]cdnFile{
+		"/a/file.txt": {body: content, contentType: "text/plain"},
+		"/b/file.txt": {body: content, contentType: "text/plain"},
+	})
+	a := newTestArchiver(t, cdn, models.AttachmentArchiveConfig{})
+
+	if err := a.Archive(1, Source{URL: cdn.URL + "/a/file.txt", Filename: "a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Archive(2, Source{URL: cdn.URL + "/b/file.txt", Filename: "b.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	// Archiving a recorded URL again does not download it.
+	if err := a.Archive(1, Source{URL: cdn.URL + "/a/file.txt", Filename: "a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if downloads := cdn.downloads("/a/file.txt"); downloads != 1 {
+		t.Fatalf("downloaded /a/file.txt %d times, want 1", downloads)
+	}
+
+	first, err := a.Attachments(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := a.Attachments(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 1 || len(second) != 1 || first[0].Hash != second[0].Hash {
+		t.Fatalf("got records %v and %v, want one each with the same hash", first, second)
+	}
+	if stored := storedObjects(t, a); len(stored) != 1 || stored[0] != first[0].Hash {
+		t.Fatalf("store holds %v, want only %s", stored, first[0].Hash)
+	}
+	if used, err := a.UsedBytes(); err != nil || used != int64(len(content)) {
+		t.Fatalf("used %d bytes (%v), want %d", used, err, len(content))
+	}
+
+	f, err := a.Open(first[0].Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stored, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, content) {
+		t.Fatalf("stored content %q", stored)
+	}
+	if _, err := a.Open(strings.Repeat("../", 3) + "attachments.db"); err == nil {
+		t.Fatal("opened a path outside the store")
+	}
+}
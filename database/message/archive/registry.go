@@ -0,0 +1,32 @@
+package archive
+
+import "sync"
+
+var (
+	registryMutex sync.RWMutex
+	registry      = make(map[string]*Archiver) // guildID -> archiver
+)
+
+// Register makes the archiver of a guild available to readers such as the gRPC API.
+func Register(guildID string, a *Archiver) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry[guildID] = a
+}
+
+// Unregister removes the archiver of a guild, if it is still the registered one.
+func Unregister(guildID string, a *Archiver) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	if registry[guildID] == a {
+		delete(registry, guildID)
+	}
+}
+
+// Lookup returns the archiver of a guild.
+func Lookup(guildID string) (*Archiver, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	a, ok := registry[guildID]
+	return a, ok
+}
@@ -2,6 +2,8 @@ package basedb
 
 import (
 	"database/sql"
+	database "discord-bot/database/message"
+	"discord-bot/database/message/stats"
 	"discord-bot/models"
 	"fmt"
 	"log"
@@ -89,13 +91,19 @@ func createMessagesTable(db *sql.DB) error {
         timestamp INTEGER NOT NULL,
         message_id TEXT PRIMARY KEY,
         channel_id TEXT NOT NULL,
-        guild_id TEXT NOT NULL
+        guild_id TEXT NOT NULL,
+        bulk_deleted INTEGER NOT NULL DEFAULT 0
     );`
 
 	if _, err := db.Exec(query); err != nil {
 		return fmt.Errorf("failed to execute table creation query: %w", err)
 	}
 
+	// Databases created before bulk deletions were tracked lack the column.
+	if err := database.AddColumnIfMissing(db, "messages", "bulk_deleted", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
 	// Optional: Create indexes for better performance on common queries.
 	indexes := []string{
 		"CREATE INDEX IF NOT EXISTS idx_author_timestamp ON messages(author_id, timestamp);",
@@ -112,22 +120,55 @@ func createMessagesTable(db *sql.DB) error {
 	return nil
 }
 
-// Close closes the database connection.
+// Close checkpoints the WAL and closes the database connection.
 func (b *BaseDB) Close() error {
 	if b.db != nil {
-		return b.db.Close()
+		return database.CheckpointAndClose(b.db)
 	}
 	return nil
 }
 
-// SaveMessage saves a single message to the database.
-// This is a simplified version for the base mode, storing only essential fields.
-func (b *BaseDB) SaveMessage(msg models.Message) error {
-	query := `
+// Stats returns a statistics query layer over the messages table. Messages removed by a
+// bulk delete are not counted.
+func (b *BaseDB) Stats() *stats.Querier {
+	return stats.New(b.db, "author_id", "bulk_deleted = 0")
+}
+
+// MarkBulkDeleted flags messages removed by a bulk delete in a single transaction.
+func (b *BaseDB) MarkBulkDeleted(messageIDs []string) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin bulk deletion transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("UPDATE messages SET bulk_deleted = 1 WHERE message_id = ?;")
+	if err != nil {
+		return fmt.Errorf("failed to prepare bulk deletion statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, messageID := range messageIDs {
+		if _, err := stmt.Exec(messageID); err != nil {
+			return fmt.Errorf("failed to mark message %s as deleted: %w", messageID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit bulk deletion: %w", err)
+	}
+	return nil
+}
+
+// saveMessageQuery inserts a message, ignoring messages already stored.
+const saveMessageQuery = `
     INSERT OR IGNORE INTO messages (author_id, timestamp, message_id, channel_id, guild_id)
     VALUES (?, ?, ?, ?, ?);`
 
-	stmt, err := b.db.Prepare(query)
+// SaveMessage saves a single message to the database.
+// This is a simplified version for the base mode, storing only essential fields.
+func (b *BaseDB) SaveMessage(msg models.Message) error {
+	stmt, err := b.db.Prepare(saveMessageQuery)
 	if err != nil {
 		return fmt.Errorf("failed to prepare insert statement for base db: %w", err)
 	}
@@ -140,3 +181,29 @@ func (b *BaseDB) SaveMessage(msg models.Message) error {
 
 	return nil
 }
+
+// SaveMessages saves a batch of messages in a single transaction.
+func (b *BaseDB) SaveMessages(msgs []models.Message) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for base db: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(saveMessageQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert statement for base db: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, msg := range msgs {
+		if _, err := stmt.Exec(msg.UserID, msg.Timestamp, msg.MessageID, msg.ChannelID, msg.GuildID); err != nil {
+			return fmt.Errorf("failed to insert message into base database: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit messages to base database: %w", err)
+	}
+	return nil
+}
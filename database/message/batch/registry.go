@@ -0,0 +1,38 @@
+package batch
+
+import "sync"
+
+// metricsSource is implemented by every Writer regardless of its record type.
+type metricsSource interface {
+	Metrics() Metrics
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = make(map[string]metricsSource) // writer name -> writer
+)
+
+func register(name string, w metricsSource) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry[name] = w
+}
+
+func unregister(name string, w metricsSource) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	if registry[name] == w {
+		delete(registry, name)
+	}
+}
+
+// AllMetrics returns the metrics of every open writer, keyed by name.
+func AllMetrics() map[string]Metrics {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	metrics := make(map[string]Metrics, len(registry))
+	for name, w := range registry {
+		metrics[name] = w.Metrics()
+	}
+	return metrics
+}
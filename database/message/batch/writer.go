@@ -0,0 +1,249 @@
+package batch
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrBufferFull is returned by Add when the buffer stayed full for the whole enqueue timeout.
+	ErrBufferFull = errors.New("write buffer is full")
+	// ErrClosed is returned by Add after Close.
+	ErrClosed = errors.New("writer is closed")
+)
+
+// fullWarningInterval limits how often a full buffer is logged.
+const fullWarningInterval = 30 * time.Second
+
+// Config controls buffering and flushing of a Writer.
+type Config struct {
+	BufferSize     int           // Records waiting to be written before Add blocks
+	BatchSize      int           // Records per transaction; a full batch is flushed immediately
+	FlushInterval  time.Duration // Maximum time a record waits in a partial batch
+	EnqueueTimeout time.Duration // How long Add blocks on a full buffer before dropping the record
+}
+
+// DefaultConfig is used for the message collector's writers.
+var DefaultConfig = Config{
+	BufferSize:     4096,
+	BatchSize:      200,
+	FlushInterval:  time.Second,
+	EnqueueTimeout: 2 * time.Second,
+}
+
+// Metrics is a snapshot of a Writer's counters.
+type Metrics struct {
+	Buffered int   `json:"buffered"` // Records waiting in the buffer
+	Capacity int   `json:"capacity"`
+	Written  int64 `json:"written"`
+	Batches  int64 `json:"batches"`
+	Failed   int64 `json:"failed"`  // Records that could not be written, even on their own
+	Blocked  int64 `json:"blocked"` // Add calls that had to wait for room in the buffer
+	Dropped  int64 `json:"dropped"` // Records rejected because the buffer stayed full
+}
+
+// Writer buffers records and writes them in batches from a background goroutine, so event
+// handlers never wait for the database. When the buffer fills, Add blocks for a bounded
+// time to slow the producer down before dropping records.
+type Writer[T any] struct {
+	name   string
+	config Config
+	write  func(items []T) error
+
+	queue    chan T
+	flushReq chan chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+
+	mutex     sync.RWMutex // Held for reading by Add, for writing by Close
+	closed    bool
+	closeOnce sync.Once
+
+	written  atomic.Int64
+	batches  atomic.Int64
+	failed   atomic.Int64
+	blocked  atomic.Int64
+	dropped  atomic.Int64
+	lastWarn atomic.Int64
+}
+
+// NewWriter starts a writer that hands batches to write, which should store them in one transaction.
+// When a batch fails, its records are retried one at a time.
+func NewWriter[T any](name string, config Config, write func(items []T) error) *Writer[T] {
+	w := &Writer[T]{
+		name:     name,
+		config:   config,
+		write:    write,
+		queue:    make(chan T, config.BufferSize),
+		flushReq: make(chan chan struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	register(name, w)
+	go w.run()
+	return w
+}
+
+// Add queues a record. It returns ErrBufferFull when the buffer stays full for
+// EnqueueTimeout and ErrClosed after Close.
+func (w *Writer[T]) Add(item T) error {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	if w.closed {
+		return ErrClosed
+	}
+
+	select {
+	case w.queue <- item:
+		return nil
+	default:
+	}
+
+	// The buffer is full: apply back-pressure to the caller for a while.
+	w.blocked.Add(1)
+	w.warnFull()
+	timer := time.NewTimer(w.config.EnqueueTimeout)
+	defer timer.Stop()
+	select {
+	case w.queue <- item:
+		return nil
+	case <-timer.C:
+		w.dropped.Add(1)
+		return ErrBufferFull
+	}
+}
+
+// Flush writes everything added so far and waits until it is stored.
+func (w *Writer[T]) Flush() {
+	ack := make(chan struct{})
+	select {
+	case w.flushReq <- ack:
+		<-ack
+	case <-w.done:
+	}
+}
+
+// Close stops accepting records, writes the remaining ones and stops the background goroutine.
+func (w *Writer[T]) Close() {
+	w.closeOnce.Do(func() {
+		// Wait for in-flight Add calls, so nothing lands in the queue after the final drain.
+		w.mutex.Lock()
+		w.closed = true
+		w.mutex.Unlock()
+
+		close(w.stop)
+		<-w.done
+		unregister(w.name, w)
+	})
+}
+
+// Metrics returns a snapshot of the writer's counters.
+func (w *Writer[T]) Metrics() Metrics {
+	return Metrics{
+		Buffered: len(w.queue),
+		Capacity: cap(w.queue),
+		Written:  w.written.Load(),
+		Batches:  w.batches.Load(),
+		Failed:   w.failed.Load(),
+		Blocked:  w.blocked.Load(),
+		Dropped:  w.dropped.Load(),
+	}
+}
+
+// run collects records into batches and flushes them on size, interval, request or close.
+func (w *Writer[T]) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]T, 0, w.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.write(batch); err != nil {
+			w.retry(batch, err)
+		} else {
+			w.written.Add(int64(len(batch)))
+		}
+		w.batches.Add(1)
+		clear(batch) // Release references held by the reused backing array
+		batch = batch[:0]
+	}
+	add := func(item T) {
+		batch = append(batch, item)
+		if len(batch) >= w.config.BatchSize {
+			flush()
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case item := <-w.queue:
+				add(item)
+			default:
+				flush()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case item := <-w.queue:
+			add(item)
+		case <-ticker.C:
+			flush()
+		case ack := <-w.flushReq:
+			drain()
+			close(ack)
+		case <-w.stop:
+			drain()
+			return
+		}
+	}
+}
+
+// retry writes the records of a failed batch one at a time, so a single bad record does not
+// take the rest of its batch with it.
+func (w *Writer[T]) retry(batch []T, batchErr error) {
+	if len(batch) == 1 {
+		w.failed.Add(1)
+		log.Printf("Writer %s: failed to write a record: %v", w.name, batchErr)
+		return
+	}
+
+	var failed int64
+	var firstErr error
+	for i := range batch {
+		if err := w.write(batch[i : i+1]); err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		} else {
+			w.written.Add(1)
+		}
+	}
+	w.failed.Add(failed)
+	if failed > 0 {
+		log.Printf("Writer %s: batch of %d records failed (%v); %d records failed on their own, first error: %v",
+			w.name, len(batch), batchErr, failed, firstErr)
+	}
+}
+
+// warnFull logs that the buffer is full, at most once per fullWarningInterval.
+func (w *Writer[T]) warnFull() {
+	now := time.Now().UnixNano()
+	last := w.lastWarn.Load()
+	if now-last < int64(fullWarningInterval) || !w.lastWarn.CompareAndSwap(last, now) {
+		return
+	}
+	metrics := w.Metrics()
+	log.Printf("Writer %s: buffer full (%d/%d), slowing down event handling; %d records dropped so far",
+		w.name, metrics.Buffered, metrics.Capacity, metrics.Dropped)
+}
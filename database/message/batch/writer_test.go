@@ -0,0 +1,193 @@
+package batch
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSink records the batches a Writer hands it. Writes wait on the gate when one is set and
+// fail for any batch holding a record listed in bad.
+type fakeSink struct {
+	mutex   sync.Mutex
+	batches [][]int
+	bad     map[int]bool
+	gate    chan struct{} // Each write waits for a value or for close when set
+	started chan struct{} // Signalled when a write begins, when set
+}
+
+func (f *fakeSink) write(items []int) error {
+	if f.started != nil {
+		f.started <- struct{}{}
+	}
+	if f.gate != nil {
+		<-f.gate
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, item := range items {
+		if f.bad[item] {
+			return errors.New("constraint failed")
+		}
+	}
+	f.batches = append(f.batches, slices.Clone(items))
+	return nil
+}
+
+// stored returns every record written so far, in order.
+func (f *fakeSink) stored() []int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var items []int
+	for _, batch := range f.batches {
+		items = append(items, batch...)
+	}
+	return items
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newTestWriter(t *testing.T, sink *fakeSink, config Config) *Writer[int] {
+	t.Helper()
+	w := NewWriter(t.Name(), config, sink.write)
+	t.Cleanup(w.Close)
+	return w
+}
+
+func TestWriterFlushTriggers(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		add         int
+		flush       func(w *Writer[int])
+		wantBatches int
+	}{
+		{
+			name:        "full batch",
+			config:      Config{BufferSize: 10, BatchSize: 3, FlushInterval: time.Hour, EnqueueTimeout: time.Second},
+			add:         7,
+			wantBatches: 2, // The seventh record waits for the interval
+		},
+		{
+			name:        "interval",
+			config:      Config{BufferSize: 10, BatchSize: 100, FlushInterval: 10 * time.Millisecond, EnqueueTimeout: time.Second},
+			add:         2,
+			wantBatches: 1,
+		},
+		{
+			name:        "Flush",
+			config:      Config{BufferSize: 10, BatchSize: 100, FlushInterval: time.Hour, EnqueueTimeout: time.Second},
+			add:         5,
+			flush:       (*Writer[int]).Flush,
+			wantBatches: 1,
+		},
+		{
+			name:        "Close",
+			config:      Config{BufferSize: 10, BatchSize: 4, FlushInterval: time.Hour, EnqueueTimeout: time.Second},
+			add:         6,
+			flush:       (*Writer[int]).Close,
+			wantBatches: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &fakeSink{}
+			w := newTestWriter(t, sink, tt.config)
+			for i := 0; i < tt.add; i++ {
+				if err := w.Add(i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.flush != nil {
+				tt.flush(w)
+			}
+
+			waitFor(t, "batches", func() bool { return w.Metrics().Batches == int64(tt.wantBatches) })
+			metrics := w.Metrics()
+			want := min(tt.add, tt.wantBatches*tt.config.BatchSize)
+			if metrics.Written != int64(want) || len(sink.stored()) != want {
+				t.Fatalf("wrote %d records (sink has %d), want %d", metrics.Written, len(sink.stored()), want)
+			}
+		})
+	}
+}
+
+func TestWriterAddAfterClose(t *testing.T) {
+	sink := &fakeSink{}
+	w := newTestWriter(t, sink, DefaultConfig)
+	w.Add(1)
+	w.Close()
+	if err := w.Add(2); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Add after Close returned %v, want ErrClosed", err)
+	}
+	if got := sink.stored(); !slices.Equal(got, []int{1}) {
+		t.Fatalf("stored %v, want [1]", got)
+	}
+	if _, ok := AllMetrics()[t.Name()]; ok {
+		t.Fatal("closed writer is still listed in AllMetrics")
+	}
+}
+
+func TestWriterBackPressure(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	sink := &fakeSink{gate: make(chan struct{}), started: make(chan struct{}, 16)}
+	w := newTestWriter(t, sink, Config{BufferSize: 2, BatchSize: 1, FlushInterval: time.Hour, EnqueueTimeout: timeout})
+	defer close(sink.gate) // Lets the remaining writes through before Close
+
+	// The first record is taken off the buffer and blocks in the sink; two more fill the buffer.
+	w.Add(0)
+	<-sink.started
+	w.Add(1)
+	w.Add(2)
+
+	start := time.Now()
+	if err := w.Add(3); !errors.Is(err, ErrBufferFull) {
+		t.Fatalf("Add on a full buffer returned %v, want ErrBufferFull", err)
+	}
+	if waited := time.Since(start); waited < timeout {
+		t.Fatalf("Add gave up after %v, before the enqueue timeout", waited)
+	}
+	if m := w.Metrics(); m.Blocked != 1 || m.Dropped != 1 || m.Buffered != 2 || m.Capacity != 2 {
+		t.Fatalf("got metrics %+v", m)
+	}
+
+	// A blocked Add succeeds once the sink makes room within the timeout.
+	go func() {
+		time.Sleep(timeout / 10)
+		sink.gate <- struct{}{}
+	}()
+	if err := w.Add(4); err != nil {
+		t.Fatalf("Add while the buffer drains returned %v", err)
+	}
+	if m := w.Metrics(); m.Blocked != 2 || m.Dropped != 1 {
+		t.Fatalf("got metrics %+v", m)
+	}
+}
+
+func TestWriterRetriesFailedBatchOneByOne(t *testing.T) {
+	sink := &fakeSink{bad: map[int]bool{2: true}}
+	w := newTestWriter(t, sink, Config{BufferSize: 10, BatchSize: 4, FlushInterval: time.Hour, EnqueueTimeout: time.Second})
+	for i := 0; i < 4; i++ {
+		w.Add(i)
+	}
+	w.Flush()
+
+	if got := sink.stored(); !slices.Equal(got, []int{0, 1, 3}) {
+		t.Fatalf("stored %v, want [0 1 3]", got)
+	}
+	if m := w.Metrics(); m.Written != 3 || m.Failed != 1 || m.Batches != 1 {
+		t.Fatalf("got metrics %+v", m)
+	}
+}
@@ -0,0 +1,20 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// CheckpointAndClose copies the write-ahead log back into the database file and truncates
+// it before closing, so a clean shutdown leaves no -wal file behind. The connection is
+// closed even when the checkpoint fails, e.g. because a reader still holds the log.
+func CheckpointAndClose(db *sql.DB) error {
+	_, checkpointErr := db.Exec("PRAGMA wal_checkpoint(TRUNCATE);")
+	if err := db.Close(); err != nil {
+		return err
+	}
+	if checkpointErr != nil {
+		return fmt.Errorf("failed to checkpoint WAL: %w", checkpointErr)
+	}
+	return nil
+}
@@ -0,0 +1,406 @@
+package plusdb
+
+import (
+	"context"
+	"database/sql"
+	"discord-bot/database/message/stats"
+	"discord-bot/models"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxAttachedPeriods keeps every batch below SQLite's default limit of 10 attached databases.
+const maxAttachedPeriods = 8
+
+// periodFile is a period database found on disk, with the time range it covers.
+type periodFile struct {
+	path  string
+	start time.Time
+	end   time.Time // Zero when the period cannot be derived from the file name
+}
+
+// SetPathSource registers a function listing additional period files of this guild,
+// typically the history recorded in db_status.json. Files moved out of the configured
+// directory are still found as long as the registry knows about them.
+func (pdb *PlusDB) SetPathSource(source func() []string) {
+	pdb.mutex.Lock()
+	defer pdb.mutex.Unlock()
+	pdb.pathSource = source
+}
+
+// QueryMessages returns the messages matching q from every period database overlapping
+// the requested range, ordered by timestamp.
+func (pdb *PlusDB) QueryMessages(q models.MessageQuery) ([]models.Message, error) {
+	files, err := pdb.periodFiles(q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+
+	var conds []string
+	var args []interface{}
+	if q.UserID != 0 {
+		conds = append(conds, "user_id = ?")
+		args = append(args, q.UserID)
+	}
+	if q.ChannelID != 0 {
+		conds = append(conds, "channel_id = ?")
+		args = append(args, q.ChannelID)
+	}
+	if q.From != nil {
+		conds = append(conds, "timestamp >= ?")
+		args = append(args, q.From.Unix())
+	}
+	if q.To != nil {
+		conds = append(conds, "timestamp < ?")
+		args = append(args, q.To.Unix())
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	// Each batch only needs to return the first offset+limit rows; the final page is cut after merging.
+	window := 0
+	if q.Limit > 0 {
+		window = max(q.Offset, 0) + q.Limit
+	}
+
+	var messages []models.Message
+	seen := make(map[int64]bool)
+	err = forEachBatch(files, func(conn *sql.Conn, schemas []string) error {
+		selects := make([]string, 0, len(schemas))
+		var batchArgs []interface{}
+		for _, schema := range schemas {
+			columns, err := messageSelectList(context.Background(), conn, schema)
+			if err != nil {
+				return err
+			}
+			selects = append(selects, `SELECT `+columns+` FROM `+schema+`.messages`+where)
+			batchArgs = append(batchArgs, args...)
+		}
+		query := strings.Join(selects, " UNION ALL ") + " ORDER BY timestamp, message_id"
+		if window > 0 {
+			query += " LIMIT ?"
+			batchArgs = append(batchArgs, window)
+		}
+
+		rows, err := conn.QueryContext(context.Background(), query, batchArgs...)
+		if err != nil {
+			return fmt.Errorf("failed to query messages: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var msg models.Message
+			if err := scanMessage(rows, &msg); err != nil {
+				return fmt.Errorf("failed to scan message: %w", err)
+			}
+			if seen[msg.MessageID] {
+				continue
+			}
+			seen[msg.MessageID] = true
+			messages = append(messages, msg)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].Timestamp != messages[j].Timestamp {
+			return messages[i].Timestamp < messages[j].Timestamp
+		}
+		return messages[i].MessageID < messages[j].MessageID
+	})
+	if q.Offset > 0 {
+		if q.Offset >= len(messages) {
+			return nil, nil
+		}
+		messages = messages[q.Offset:]
+	}
+	if q.Limit > 0 && len(messages) > q.Limit {
+		messages = messages[:q.Limit]
+	}
+	return messages, nil
+}
+
+// EditHistory returns every recorded edit of a message in chronological order. Edits are
+// stored in the period current at the time of the edit, so every period from the message's
+// creation onwards is searched.
+func (pdb *PlusDB) EditHistory(messageID int64) ([]models.MessageEdit, error) {
+	createdAt := snowflakeTime(messageID)
+	files, err := pdb.periodFiles(&createdAt, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var edits []models.MessageEdit
+	err = forEachBatch(files, func(conn *sql.Conn, schemas []string) error {
+		selects := make([]string, 0, len(schemas))
+		var args []interface{}
+		for _, schema := range schemas {
+			selects = append(selects, `SELECT edit_id, message_id, guild_id, channel_id, original_content, edited_content,
+			    original_attachments, edited_attachments, edit_timestamp
+			    FROM `+schema+`.message_edits WHERE message_id = ?`)
+			args = append(args, messageID)
+		}
+		query := strings.Join(selects, " UNION ALL ") + " ORDER BY edit_timestamp"
+
+		rows, err := conn.QueryContext(context.Background(), query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query edits of message %d: %w", messageID, err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var edit models.MessageEdit
+			if err := rows.Scan(
+				&edit.EditID, &edit.MessageID, &edit.GuildID, &edit.ChannelID, &edit.OriginalContent,
+				&edit.EditedContent, &edit.OriginalAttachments, &edit.EditedAttachments, &edit.EditTimestamp,
+			); err != nil {
+				return fmt.Errorf("failed to scan message edit: %w", err)
+			}
+			edits = append(edits, edit)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Batches are visited in period order, so a stable sort keeps edits with equal timestamps in insertion order.
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].EditTimestamp < edits[j].EditTimestamp
+	})
+	return edits, nil
+}
+
+// DeletedMessages returns the most recent deletions in a channel since the given time, newest
+// first, each joined with the original message row from the period it was written to.
+func (pdb *PlusDB) DeletedMessages(channelID int64, since *time.Time, limit int) ([]models.DeletedMessage, error) {
+	files, err := pdb.periodFiles(since, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conds := []string{"channel_id = ?"}
+	args := []interface{}{channelID}
+	if since != nil {
+		conds = append(conds, "deletion_timestamp >= ?")
+		args = append(args, since.Unix())
+	}
+	where := " WHERE " + strings.Join(conds, " AND ")
+
+	// A message can be reported deleted more than once, e.g. by a bulk delete and a single
+	// delete, possibly in different periods. Each batch returns its latest deletion per message
+	// so duplicates do not use up the limit; the batches are then merged the same way.
+	latest := make(map[int64]models.MessageDeletion)
+	err = forEachBatch(files, func(conn *sql.Conn, schemas []string) error {
+		selects := make([]string, 0, len(schemas))
+		var batchArgs []interface{}
+		for _, schema := range schemas {
+			selects = append(selects, `SELECT deletion_id, message_id, guild_id, channel_id, deletion_timestamp
+			    FROM `+schema+`.message_deletions`+where)
+			batchArgs = append(batchArgs, args...)
+		}
+		// SQLite takes the other columns from the row holding the MAX.
+		query := `SELECT deletion_id, message_id, guild_id, channel_id, MAX(deletion_timestamp) AS latest
+		    FROM (` + strings.Join(selects, " UNION ALL ") + `) GROUP BY message_id ORDER BY latest DESC`
+		if limit > 0 {
+			query += " LIMIT ?"
+			batchArgs = append(batchArgs, limit)
+		}
+
+		rows, err := conn.QueryContext(context.Background(), query, batchArgs...)
+		if err != nil {
+			return fmt.Errorf("failed to query deletions in channel %d: %w", channelID, err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var deletion models.MessageDeletion
+			if err := rows.Scan(&deletion.DeletionID, &deletion.MessageID, &deletion.GuildID, &deletion.ChannelID, &deletion.DeletionTimestamp); err != nil {
+				return fmt.Errorf("failed to scan message deletion: %w", err)
+			}
+			if previous, ok := latest[deletion.MessageID]; !ok || deletion.DeletionTimestamp > previous.DeletionTimestamp {
+				latest[deletion.MessageID] = deletion
+			}
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deletions := make([]models.MessageDeletion, 0, len(latest))
+	for _, deletion := range latest {
+		deletions = append(deletions, deletion)
+	}
+	sort.Slice(deletions, func(i, j int) bool {
+		if deletions[i].DeletionTimestamp != deletions[j].DeletionTimestamp {
+			return deletions[i].DeletionTimestamp > deletions[j].DeletionTimestamp
+		}
+		return deletions[i].MessageID > deletions[j].MessageID
+	})
+	if limit > 0 && len(deletions) > limit {
+		deletions = deletions[:limit]
+	}
+
+	// The original row lives in the period the message was created in, which may be older than since.
+	results := make([]models.DeletedMessage, 0, len(deletions))
+	for _, deletion := range deletions {
+		original, err := pdb.GetMessage(deletion.MessageID)
+		if err != nil {
+			log.Printf("Failed to load deleted message %d: %v", deletion.MessageID, err)
+		}
+		results = append(results, models.DeletedMessage{MessageDeletion: deletion, Original: original})
+	}
+	return results, nil
+}
+
+// statsTables is the stats.Source of a plus database. Every batch of attached periods is read
+// through one UNION ALL subquery exposing the columns statistics are computed from.
+func (pdb *PlusDB) statsTables(from, to *time.Time, fn func(db stats.Queryer, table string) error) error {
+	files, err := pdb.periodFiles(from, to)
+	if err != nil {
+		return err
+	}
+	return forEachBatch(files, func(conn *sql.Conn, schemas []string) error {
+		selects := make([]string, 0, len(schemas))
+		for _, schema := range schemas {
+			selects = append(selects, `SELECT channel_id, user_id, timestamp FROM `+schema+`.messages`)
+		}
+		return fn(conn, "("+strings.Join(selects, " UNION ALL ")+")")
+	})
+}
+
+// periodFiles lists the existing period databases of this guild that overlap [from, to).
+// Files whose period cannot be derived from the name are always included.
+func (pdb *PlusDB) periodFiles(from, to *time.Time) ([]periodFile, error) {
+	paths, err := pdb.PeriodPaths()
+	if err != nil {
+		return nil, err
+	}
+	pdb.mutex.RLock()
+	source := pdb.pathSource
+	pdb.mutex.RUnlock()
+	if source != nil {
+		paths = append(paths, source()...)
+	}
+
+	seen := make(map[string]bool)
+	var files []periodFile
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		if _, err := os.Stat(path); err != nil {
+			continue // Registered but removed from disk
+		}
+
+		file := periodFile{path: path}
+		file.start, file.end = pdb.parsePeriod(path)
+		if !file.end.IsZero() {
+			if from != nil && !file.end.After(*from) {
+				continue
+			}
+			if to != nil && !file.start.Before(*to) {
+				continue
+			}
+		}
+		files = append(files, file)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].start.Equal(files[j].start) {
+			return files[i].start.Before(files[j].start)
+		}
+		return files[i].path < files[j].path
+	})
+	return files, nil
+}
+
+// parsePeriod derives the [start, end) range of a period file from the $time_type part of its name.
+// Every naming scheme of getDBPath is recognised, so files survive a change of the configured time type.
+func (pdb *PlusDB) parsePeriod(path string) (time.Time, time.Time) {
+	template := filepath.Clean(pdb.config.DBPath)
+	prefix, suffix, ok := strings.Cut(template, "$time_type")
+	if !ok || !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) || len(path) < len(prefix)+len(suffix) {
+		return time.Time{}, time.Time{}
+	}
+	token := path[len(prefix) : len(path)-len(suffix)]
+
+	var year, n int
+	if day, err := time.ParseInLocation("2006-01-02", token, time.Local); err == nil {
+		return day, day.AddDate(0, 0, 1)
+	}
+	if _, err := fmt.Sscanf(token, "%d-week-%d", &year, &n); err == nil {
+		// ISO week 1 is the week containing January 4th.
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.Local)
+		start := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(n-1)*7)
+		return start, start.AddDate(0, 0, 7)
+	}
+	if _, err := fmt.Sscanf(token, "%d-month-%d", &year, &n); err == nil {
+		start := time.Date(year, time.Month(n), 1, 0, 0, 0, 0, time.Local)
+		return start, start.AddDate(0, 1, 0)
+	}
+	return time.Time{}, time.Time{}
+}
+
+// forEachBatch attaches the period files read-only to a private in-memory connection, a few
+// at a time, and calls fn with the schema names of each batch. Files that cannot be attached
+// are logged and skipped so one damaged period does not hide the others.
+func forEachBatch(files []periodFile, fn func(conn *sql.Conn, schemas []string) error) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return fmt.Errorf("failed to open query database: %w", err)
+	}
+	defer db.Close()
+
+	// ATTACH is per connection, so every statement has to run on the same one.
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open query connection: %w", err)
+	}
+	defer conn.Close()
+
+	for start := 0; start < len(files); start += maxAttachedPeriods {
+		batch := files[start:min(start+maxAttachedPeriods, len(files))]
+		schemas := make([]string, 0, len(batch))
+		for i, file := range batch {
+			schema := fmt.Sprintf("p%d", i)
+			if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS "+schema, "file:"+file.path+"?mode=ro"); err != nil {
+				log.Printf("Skipping period database %s: %v", file.path, err)
+				continue
+			}
+			schemas = append(schemas, schema)
+		}
+		if len(schemas) == 0 {
+			continue
+		}
+
+		err := fn(conn, schemas)
+		for _, schema := range schemas {
+			if _, detachErr := conn.ExecContext(ctx, "DETACH DATABASE "+schema); detachErr != nil {
+				log.Printf("Warning: failed to detach %s: %v", schema, detachErr)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package plusdb
+
+import (
+	"discord-bot/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestDeletedMessagesDeduplicates checks that a message deleted more than once, in one
+// period or several, is listed once with its latest deletion and does not use up the limit.
+func TestDeletedMessagesDeduplicates(t *testing.T) {
+	config := models.PlusGuildConfig{
+		GuildsID: "1",
+		DBPath:   filepath.Join(t.TempDir(), "messages_$time_type.db"),
+		TimeType: "day",
+	}
+	pdb, err := NewPlusDB(config)
+	if err != nil {
+		t.Fatalf("NewPlusDB: %v", err)
+	}
+	defer pdb.Close()
+
+	now := time.Now()
+	earlier := now.AddDate(0, 0, -3)
+	previous, err := openPeriodDB(config, earlier)
+	if err != nil {
+		t.Fatalf("openPeriodDB: %v", err)
+	}
+	for _, messageID := range []int64{1, 3} {
+		if _, err := previous.db.Exec(`INSERT INTO message_deletions (message_id, guild_id, channel_id, deletion_timestamp)
+		    VALUES (?, 1, 10, ?)`, messageID, earlier.Unix()); err != nil {
+			t.Fatalf("insert into previous period: %v", err)
+		}
+	}
+	previous.db.Close()
+
+	// Message 1 is reported deleted twice more, e.g. by a bulk delete and a single delete.
+	if err := pdb.InsertMessageDeletions([]models.MessageDeletion{
+		{MessageID: 1, GuildID: 1, ChannelID: 10, DeletionTimestamp: now.Unix() - 1},
+		{MessageID: 1, GuildID: 1, ChannelID: 10, DeletionTimestamp: now.Unix()},
+		{MessageID: 2, GuildID: 1, ChannelID: 10, DeletionTimestamp: now.Unix() - 10},
+		{MessageID: 4, GuildID: 1, ChannelID: 20, DeletionTimestamp: now.Unix()},
+	}); err != nil {
+		t.Fatalf("InsertMessageDeletions: %v", err)
+	}
+
+	since := now.AddDate(0, 0, -7)
+	tests := []struct {
+		name  string
+		limit int
+		want  []int64
+	}{
+		{"limit", 2, []int64{1, 2}},
+		{"no limit", 0, []int64{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted, err := pdb.DeletedMessages(10, &since, tt.limit)
+			if err != nil {
+				t.Fatalf("DeletedMessages: %v", err)
+			}
+			var got []int64
+			for _, d := range deleted {
+				got = append(got, d.MessageID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got messages %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got messages %v, want %v", got, tt.want)
+				}
+			}
+			if deleted[0].DeletionTimestamp != now.Unix() {
+				t.Errorf("message 1 listed with deletion at %d, want the latest %d", deleted[0].DeletionTimestamp, now.Unix())
+			}
+		})
+	}
+}
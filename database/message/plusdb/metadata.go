@@ -0,0 +1,89 @@
+package plusdb
+
+import (
+	"context"
+	"database/sql"
+	database "discord-bot/database/message"
+	"discord-bot/models"
+	"fmt"
+	"strings"
+)
+
+// metadataColumns are the message columns added after the original schema. Databases of
+// earlier periods are migrated when opened for writing; read-only queries over files that
+// were never migrated select the fallback value instead.
+var metadataColumns = []struct {
+	name       string
+	definition string
+	fallback   string
+}{
+	{"reply_to_id", "INTEGER NOT NULL DEFAULT 0", "0"},
+	{"mentioned_users", "TEXT DEFAULT ''", "''"},
+	{"mentioned_roles", "TEXT DEFAULT ''", "''"},
+	{"embeds", "TEXT DEFAULT ''", "''"},
+	{"sticker_ids", "TEXT DEFAULT ''", "''"},
+	{"message_type", "INTEGER NOT NULL DEFAULT 0", "0"},
+	{"in_thread", "BOOLEAN DEFAULT FALSE", "FALSE"},
+}
+
+// baseMessageColumns are the columns every plus database has had from the start.
+const baseMessageColumns = "message_id, user_id, guild_id, channel_id, timestamp, message_content, attachments, is_edited"
+
+// migrateMessagesTable adds the metadata columns missing from an existing messages table.
+func migrateMessagesTable(db *sql.DB) error {
+	for _, column := range metadataColumns {
+		if err := database.AddColumnIfMissing(db, "messages", column.name, column.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryer is implemented by both *sql.DB and *sql.Conn.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// messageSelectList returns the column list for reading messages from schema, substituting
+// fallbacks for metadata columns the file does not have yet. The order matches scanMessage.
+func messageSelectList(ctx context.Context, q queryer, schema string) (string, error) {
+	rows, err := q.QueryContext(ctx, fmt.Sprintf("PRAGMA %s.table_info(messages);", schema))
+	if err != nil {
+		return "", fmt.Errorf("failed to read message columns: %w", err)
+	}
+	defer rows.Close()
+
+	present := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, columnType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk); err != nil {
+			return "", fmt.Errorf("failed to scan message column: %w", err)
+		}
+		present[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to read message columns: %w", err)
+	}
+
+	columns := []string{baseMessageColumns}
+	for _, column := range metadataColumns {
+		if present[column.name] {
+			columns = append(columns, column.name)
+		} else {
+			columns = append(columns, column.fallback+" AS "+column.name)
+		}
+	}
+	return strings.Join(columns, ", "), nil
+}
+
+// scanMessage reads a row selected with messageSelectList.
+func scanMessage(row interface{ Scan(...any) error }, msg *models.Message) error {
+	return row.Scan(
+		&msg.MessageID, &msg.UserID, &msg.GuildID, &msg.ChannelID,
+		&msg.Timestamp, &msg.MessageContent, &msg.Attachments, &msg.IsEdited,
+		&msg.ReplyToID, &msg.MentionedUsers, &msg.MentionedRoles, &msg.Embeds,
+		&msg.StickerIDs, &msg.MessageType, &msg.InThread,
+	)
+}
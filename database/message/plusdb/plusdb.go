@@ -1,7 +1,10 @@
 package plusdb
 
 import (
+	"context"
 	"database/sql"
+	database "discord-bot/database/message"
+	"discord-bot/database/message/stats"
 	"discord-bot/models"
 	"fmt"
 	"log"
@@ -14,18 +17,70 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// rotationGrace is how long the previous period's database stays open after a rotation,
+// so late edits and deletions of its messages can still read the originals cheaply.
+const rotationGrace = 10 * time.Minute
+
+// periodDB is the database file of a single $time_type period.
+type periodDB struct {
+	path  string
+	db    *sql.DB
+	start time.Time
+	end   time.Time // Zero when the path does not rotate
+
+	columnsMutex sync.Mutex
+	columns      string // messageSelectList of the file, read on first lookup
+}
+
+// selectList returns the message column list of the file, reading its schema only once.
+// Columns are only ever added, by migrateMessagesTable before the handle is shared.
+func (p *periodDB) selectList() (string, error) {
+	p.columnsMutex.Lock()
+	defer p.columnsMutex.Unlock()
+	if p.columns == "" {
+		columns, err := messageSelectList(context.Background(), p.db, "main")
+		if err != nil {
+			return "", err
+		}
+		p.columns = columns
+	}
+	return p.columns, nil
+}
+
 // PlusDB handles message database operations for the plus mode.
+// When the configured path contains $time_type, writes go to the file of the current
+// period and PlusDB rolls over to a new file as soon as the period boundary passes.
 type PlusDB struct {
-	db     *sql.DB
-	config models.PlusGuildConfig
-	mutex  sync.RWMutex
+	config   models.PlusGuildConfig
+	mutex    sync.RWMutex
+	current  *periodDB
+	previous map[string]*periodDB // path -> database kept open during rotationGrace
+	onRotate []func(dbPath string)
+	// pathSource lists period files known outside the database directory, e.g. from db_status.json.
+	pathSource func() []string
 }
 
 // NewPlusDB creates a new message database manager for the plus mode.
 func NewPlusDB(config models.PlusGuildConfig) (*PlusDB, error) {
-	dbPath, err := getDBPath(config, time.Now())
+	current, err := openPeriodDB(config, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Plus database initialized for guild %s at %s", config.GuildsID, current.path)
+
+	return &PlusDB{
+		config:   config,
+		current:  current,
+		previous: make(map[string]*periodDB),
+	}, nil
+}
+
+// openPeriodDB opens (creating if needed) the database of the period containing t.
+func openPeriodDB(config models.PlusGuildConfig, t time.Time) (*periodDB, error) {
+	dbPath, err := getDBPath(config, t)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate initial DB path: %w", err)
+		return nil, fmt.Errorf("failed to generate DB path: %w", err)
 	}
 
 	// Ensure the directory for the database file exists.
@@ -44,12 +99,8 @@ func NewPlusDB(config models.PlusGuildConfig) (*PlusDB, error) {
 		return nil, err
 	}
 
-	log.Printf("Plus database initialized for guild %s at %s", config.GuildsID, dbPath)
-
-	return &PlusDB{
-		db:     db,
-		config: config,
-	}, nil
+	start, end := periodBounds(config, t)
+	return &periodDB{path: dbPath, db: db, start: start, end: end}, nil
 }
 
 // createTables creates all necessary tables for the plus mode.
@@ -66,6 +117,88 @@ func createTables(db *sql.DB) error {
 	return nil
 }
 
+// OnRotate registers a callback invoked with the new database path after every rotation.
+func (pdb *PlusDB) OnRotate(callback func(dbPath string)) {
+	pdb.mutex.Lock()
+	defer pdb.mutex.Unlock()
+	pdb.onRotate = append(pdb.onRotate, callback)
+}
+
+// CurrentPath returns the database path of the current period.
+func (pdb *PlusDB) CurrentPath() string {
+	pdb.mutex.RLock()
+	defer pdb.mutex.RUnlock()
+	return pdb.current.path
+}
+
+// PeriodPaths returns the paths of every existing period file of this guild, including the current one.
+func (pdb *PlusDB) PeriodPaths() ([]string, error) {
+	if !strings.Contains(pdb.config.DBPath, "$time_type") {
+		return []string{pdb.config.DBPath}, nil
+	}
+	pattern := strings.Replace(pdb.config.DBPath, "$time_type", "*", 1)
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list period databases for guild %s: %w", pdb.config.GuildsID, err)
+	}
+	// Skip SQLite side files and corrupted backups that share the prefix.
+	valid := paths[:0]
+	for _, path := range paths {
+		if strings.HasSuffix(path, filepath.Ext(pdb.config.DBPath)) {
+			valid = append(valid, path)
+		}
+	}
+	return valid, nil
+}
+
+// rotate switches to the database of the current period if the boundary has passed.
+func (pdb *PlusDB) rotate(now time.Time) {
+	pdb.mutex.RLock()
+	due := !pdb.current.end.IsZero() && !now.Before(pdb.current.end)
+	pdb.mutex.RUnlock()
+	if !due {
+		return
+	}
+
+	pdb.mutex.Lock()
+	// Another goroutine may have rotated while we waited for the lock.
+	if pdb.current.end.IsZero() || now.Before(pdb.current.end) {
+		pdb.mutex.Unlock()
+		return
+	}
+
+	next, err := openPeriodDB(pdb.config, now)
+	if err != nil {
+		pdb.mutex.Unlock()
+		log.Printf("Failed to rotate plus database for guild %s, still writing to %s: %v", pdb.config.GuildsID, pdb.current.path, err)
+		return
+	}
+
+	old := pdb.current
+	pdb.current = next
+	pdb.previous[old.path] = old
+	callbacks := append([]func(string){}, pdb.onRotate...)
+	pdb.mutex.Unlock()
+
+	log.Printf("Plus database for guild %s rotated from %s to %s", pdb.config.GuildsID, old.path, next.path)
+
+	time.AfterFunc(rotationGrace, func() {
+		pdb.mutex.Lock()
+		defer pdb.mutex.Unlock()
+		if _, ok := pdb.previous[old.path]; !ok {
+			return // Already closed by Close
+		}
+		delete(pdb.previous, old.path)
+		if err := old.db.Close(); err != nil {
+			log.Printf("Error closing previous plus database %s: %v", old.path, err)
+		}
+	})
+
+	for _, callback := range callbacks {
+		callback(next.path)
+	}
+}
+
 // getDBPath generates a database path based on the configuration and a given time.
 func getDBPath(config models.PlusGuildConfig, t time.Time) (string, error) {
 	basePath := config.DBPath
@@ -87,19 +220,61 @@ func getDBPath(config models.PlusGuildConfig, t time.Time) (string, error) {
 	return strings.Replace(basePath, "$time_type", timeSuffix, 1), nil
 }
 
-// GetDB returns the database connection.
-// In this refactored module, we assume one PlusDB instance per guild,
-// so we don't need to manage a map of connections.
+// periodBounds returns the [start, end) range of the period containing t.
+// The end is zero when the path has no $time_type placeholder or the time type never rotates.
+func periodBounds(config models.PlusGuildConfig, t time.Time) (time.Time, time.Time) {
+	if !strings.Contains(config.DBPath, "$time_type") {
+		return time.Time{}, time.Time{}
+	}
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch config.TimeType {
+	case "week":
+		// ISO weeks start on Monday.
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7)
+	case "month":
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(0, 1, 0)
+	case "day":
+		return day, day.AddDate(0, 0, 1)
+	default:
+		return time.Time{}, time.Time{}
+	}
+}
+
+// GetDB returns the database connection of the current period, rotating first if the period has ended.
 func (pdb *PlusDB) GetDB() *sql.DB {
-	return pdb.db
+	return pdb.currentPeriod().db
+}
+
+// currentPeriod returns the database of the current period, rotating first if it has ended.
+func (pdb *PlusDB) currentPeriod() *periodDB {
+	pdb.rotate(time.Now())
+	pdb.mutex.RLock()
+	defer pdb.mutex.RUnlock()
+	return pdb.current
 }
 
-// Close closes the database connection.
+// Stats returns a statistics query layer over the messages of every period overlapping the
+// queried range, so ranges longer than one $time_type period are counted in full.
+func (pdb *PlusDB) Stats() *stats.Querier {
+	return stats.NewFromSource(pdb.statsTables, "user_id")
+}
+
+// Close checkpoints the WAL of the current database and any previous ones still open, then closes them.
 func (pdb *PlusDB) Close() error {
 	pdb.mutex.Lock()
 	defer pdb.mutex.Unlock()
-	if pdb.db != nil {
-		return pdb.db.Close()
+	for path, period := range pdb.previous {
+		if err := database.CheckpointAndClose(period.db); err != nil {
+			log.Printf("Error closing previous plus database %s: %v", path, err)
+		}
+		delete(pdb.previous, path)
+	}
+	if pdb.current != nil && pdb.current.db != nil {
+		return database.CheckpointAndClose(pdb.current.db)
 	}
 	return nil
 }
@@ -117,17 +292,30 @@ func createMessagesTable(db *sql.DB) error {
         timestamp INTEGER NOT NULL,
         message_content TEXT NOT NULL,
         attachments TEXT DEFAULT '',
-        is_edited BOOLEAN DEFAULT FALSE
+        is_edited BOOLEAN DEFAULT FALSE,
+        reply_to_id INTEGER NOT NULL DEFAULT 0,
+        mentioned_users TEXT DEFAULT '',
+        mentioned_roles TEXT DEFAULT '',
+        embeds TEXT DEFAULT '',
+        sticker_ids TEXT DEFAULT '',
+        message_type INTEGER NOT NULL DEFAULT 0,
+        in_thread BOOLEAN DEFAULT FALSE
     );`
 
 	if _, err := db.Exec(query); err != nil {
 		return fmt.Errorf("failed to create messages table: %w", err)
 	}
 
+	// Databases created before message metadata was recorded lack the new columns.
+	if err := migrateMessagesTable(db); err != nil {
+		return err
+	}
+
 	indexes := []string{
 		"CREATE INDEX IF NOT EXISTS idx_user_timestamp ON messages(user_id, timestamp);",
 		"CREATE INDEX IF NOT EXISTS idx_channel_timestamp ON messages(channel_id, timestamp);",
 		"CREATE INDEX IF NOT EXISTS idx_guild_timestamp ON messages(guild_id, timestamp);",
+		"CREATE INDEX IF NOT EXISTS idx_reply_to ON messages(reply_to_id) WHERE reply_to_id != 0;",
 	}
 
 	for _, indexQuery := range indexes {
@@ -200,25 +388,57 @@ func createMessageEditsTable(db *sql.DB) error {
 	return nil
 }
 
+// insertMessageQuery inserts a message record, ignoring messages already stored
+const insertMessageQuery = `INSERT OR IGNORE INTO messages (message_id, user_id, guild_id, channel_id, timestamp, message_content, attachments, is_edited,
+                  reply_to_id, mentioned_users, mentioned_roles, embeds, sticker_ids, message_type, in_thread) 
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
 // InsertMessage inserts a message record into the database
 func (pdb *PlusDB) InsertMessage(msg models.Message) error {
 	db := pdb.GetDB()
-	query := `INSERT OR IGNORE INTO messages (message_id, user_id, guild_id, channel_id, timestamp, message_content, attachments, is_edited) 
-              VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-
-	stmt, err := db.Prepare(query)
+	stmt, err := db.Prepare(insertMessageQuery)
 	if err != nil {
 		return fmt.Errorf("failed to prepare insert statement: %w", err)
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(msg.MessageID, msg.UserID, msg.GuildID, msg.ChannelID, msg.Timestamp, msg.MessageContent, msg.Attachments, msg.IsEdited)
+	_, err = stmt.Exec(msg.MessageID, msg.UserID, msg.GuildID, msg.ChannelID, msg.Timestamp, msg.MessageContent, msg.Attachments, msg.IsEdited,
+		msg.ReplyToID, msg.MentionedUsers, msg.MentionedRoles, msg.Embeds, msg.StickerIDs, msg.MessageType, msg.InThread)
 	if err != nil {
 		return fmt.Errorf("failed to insert message %d: %w", msg.MessageID, err)
 	}
 	return nil
 }
 
+// InsertMessages inserts a batch of message records in a single transaction
+func (pdb *PlusDB) InsertMessages(msgs []models.Message) error {
+	db := pdb.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin message transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(insertMessageQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, msg := range msgs {
+		_, err := stmt.Exec(msg.MessageID, msg.UserID, msg.GuildID, msg.ChannelID, msg.Timestamp, msg.MessageContent, msg.Attachments, msg.IsEdited,
+			msg.ReplyToID, msg.MentionedUsers, msg.MentionedRoles, msg.Embeds, msg.StickerIDs, msg.MessageType, msg.InThread)
+		if err != nil {
+			return fmt.Errorf("failed to insert message %d: %w", msg.MessageID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit messages: %w", err)
+	}
+	return nil
+}
+
 // InsertMessageDeletion records a message deletion event
 func (pdb *PlusDB) InsertMessageDeletion(deletion models.MessageDeletion) error {
 	db := pdb.GetDB()
@@ -238,6 +458,34 @@ func (pdb *PlusDB) InsertMessageDeletion(deletion models.MessageDeletion) error
 	return nil
 }
 
+// InsertMessageDeletions records the deletions of a bulk delete in a single transaction
+func (pdb *PlusDB) InsertMessageDeletions(deletions []models.MessageDeletion) error {
+	db := pdb.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin deletion transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO message_deletions (message_id, guild_id, channel_id, deletion_timestamp) 
+              VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare deletion insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, deletion := range deletions {
+		if _, err := stmt.Exec(deletion.MessageID, deletion.GuildID, deletion.ChannelID, deletion.DeletionTimestamp); err != nil {
+			return fmt.Errorf("failed to insert message deletion %d: %w", deletion.MessageID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit message deletions: %w", err)
+	}
+	return nil
+}
+
 // InsertMessageEdit records a message edit event
 func (pdb *PlusDB) InsertMessageEdit(edit models.MessageEdit) error {
 	db := pdb.GetDB()
@@ -257,17 +505,68 @@ func (pdb *PlusDB) InsertMessageEdit(edit models.MessageEdit) error {
 	return nil
 }
 
-// GetMessage retrieves a single message by its ID from the database.
+// GetMessage retrieves a single message by its ID. The message is looked up in the
+// period file matching its creation time (derived from the snowflake ID) and then in
+// the current period. It returns nil, nil if no message is found.
 func (pdb *PlusDB) GetMessage(messageID int64) (*models.Message, error) {
-	db := pdb.GetDB()
-	query := `SELECT message_id, user_id, guild_id, channel_id, timestamp, message_content, attachments, is_edited
-	             FROM messages WHERE message_id = ?`
+	current := pdb.currentPeriod()
+
+	createdAt := snowflakeTime(messageID)
+	if dbPath, err := getDBPath(pdb.config, createdAt); err == nil && dbPath != pdb.CurrentPath() {
+		msg, err := pdb.getMessageFromPath(dbPath, messageID)
+		if err != nil || msg != nil {
+			return msg, err
+		}
+	}
+
+	columns, err := current.selectList()
+	if err != nil {
+		return nil, err
+	}
+	return getMessage(current.db, columns, messageID)
+}
+
+// getMessageFromPath looks a message up in the database file of another period,
+// reusing its handle if it is still open after a rotation.
+func (pdb *PlusDB) getMessageFromPath(dbPath string, messageID int64) (*models.Message, error) {
+	pdb.mutex.RLock()
+	period, open := pdb.previous[dbPath]
+	var msg *models.Message
+	var err error
+	if open {
+		// Query while holding the lock so the grace timer cannot close the handle underneath us.
+		var columns string
+		if columns, err = period.selectList(); err == nil {
+			msg, err = getMessage(period.db, columns, messageID)
+		}
+	}
+	pdb.mutex.RUnlock()
+	if open {
+		return msg, err
+	}
+
+	if _, statErr := os.Stat(dbPath); statErr != nil {
+		return nil, nil // No database for that period
+	}
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open period database %s: %w", dbPath, err)
+	}
+	defer db.Close()
+	columns, err := messageSelectList(context.Background(), db, "main")
+	if err != nil {
+		return nil, err
+	}
+	return getMessage(db, columns, messageID)
+}
+
+// getMessage reads a single message from a plus database, selecting columns as returned
+// by messageSelectList for that file.
+func getMessage(db *sql.DB, columns string, messageID int64) (*models.Message, error) {
+	query := `SELECT ` + columns + ` FROM messages WHERE message_id = ?`
 
 	var msg models.Message
-	err := db.QueryRow(query, messageID).Scan(
-		&msg.MessageID, &msg.UserID, &msg.GuildID, &msg.ChannelID,
-		&msg.Timestamp, &msg.MessageContent, &msg.Attachments, &msg.IsEdited,
-	)
+	err := scanMessage(db.QueryRow(query, messageID), &msg)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -278,3 +577,11 @@ func (pdb *PlusDB) GetMessage(messageID int64) (*models.Message, error) {
 
 	return &msg, nil
 }
+
+// discordEpoch is the first millisecond of 2015, the epoch of Discord snowflake IDs.
+const discordEpoch = 1420070400000
+
+// snowflakeTime returns the creation time encoded in a Discord snowflake ID.
+func snowflakeTime(id int64) time.Time {
+	return time.UnixMilli((id >> 22) + discordEpoch)
+}
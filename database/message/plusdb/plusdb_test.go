@@ -0,0 +1,47 @@
+package plusdb
+
+import (
+	"discord-bot/models"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGetMessageCachesColumns checks that lookups reuse the column list read on the first one
+// instead of reading the schema of the period file every time.
+func TestGetMessageCachesColumns(t *testing.T) {
+	pdb, err := NewPlusDB(models.PlusGuildConfig{
+		GuildsID: "1",
+		DBPath:   filepath.Join(t.TempDir(), "messages_$time_type.db"),
+		TimeType: "day",
+	})
+	if err != nil {
+		t.Fatalf("NewPlusDB: %v", err)
+	}
+	defer pdb.Close()
+
+	now := time.Now()
+	messageID := (now.UnixMilli() - discordEpoch) << 22
+	if err := pdb.InsertMessage(models.Message{MessageID: messageID, GuildID: 1, ChannelID: 10, Timestamp: now.Unix(), MessageContent: "hello"}); err != nil {
+		t.Fatalf("InsertMessage: %v", err)
+	}
+
+	msg, err := pdb.GetMessage(messageID)
+	if err != nil || msg == nil || msg.MessageContent != "hello" {
+		t.Fatalf("GetMessage = %+v, %v", msg, err)
+	}
+	current := pdb.currentPeriod()
+	if current.columns == "" {
+		t.Fatal("column list was not cached after the first lookup")
+	}
+
+	// A later lookup must select with the cached list.
+	current.columnsMutex.Lock()
+	current.columns = strings.Replace(current.columns, "message_content", "'cached' AS message_content", 1)
+	current.columnsMutex.Unlock()
+	msg, err = pdb.GetMessage(messageID)
+	if err != nil || msg == nil || msg.MessageContent != "cached" {
+		t.Fatalf("GetMessage after caching = %+v, %v", msg, err)
+	}
+}
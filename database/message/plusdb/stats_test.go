@@ -0,0 +1,90 @@
+package plusdb
+
+import (
+	"discord-bot/database/message/stats"
+	"discord-bot/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestStatsSpansPeriods checks that statistics count the messages of previous period files,
+// not only those of the current one.
+func TestStatsSpansPeriods(t *testing.T) {
+	config := models.PlusGuildConfig{
+		GuildsID: "1",
+		DBPath:   filepath.Join(t.TempDir(), "messages_$time_type.db"),
+		TimeType: "day",
+	}
+	pdb, err := NewPlusDB(config)
+	if err != nil {
+		t.Fatalf("NewPlusDB: %v", err)
+	}
+	defer pdb.Close()
+
+	now := time.Now()
+	earlier := now.AddDate(0, 0, -3)
+	previous, err := openPeriodDB(config, earlier)
+	if err != nil {
+		t.Fatalf("openPeriodDB: %v", err)
+	}
+	for id, channelID := range []int64{10, 10, 20} {
+		if _, err := previous.db.Exec(insertMessageQuery, id+1, 100, 1, channelID, earlier.Unix(), "old", "", false, 0, "", "", "", "", 0, false); err != nil {
+			t.Fatalf("insert into previous period: %v", err)
+		}
+	}
+	previous.db.Close()
+
+	if err := pdb.InsertMessages([]models.Message{
+		{MessageID: 11, UserID: 100, GuildID: 1, ChannelID: 10, Timestamp: now.Unix()},
+		{MessageID: 12, UserID: 200, GuildID: 1, ChannelID: 30, Timestamp: now.Unix()},
+	}); err != nil {
+		t.Fatalf("InsertMessages: %v", err)
+	}
+
+	from := now.AddDate(0, 0, -7)
+	querier := pdb.Stats()
+
+	count, err := querier.MessageCount(models.StatsFilter{From: &from})
+	if err != nil {
+		t.Fatalf("MessageCount: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("MessageCount = %d, want 5", count)
+	}
+
+	channels, err := querier.ChannelStats(models.StatsFilter{From: &from, Limit: 2})
+	if err != nil {
+		t.Fatalf("ChannelStats: %v", err)
+	}
+	want := []models.ChannelStat{{ChannelID: 10, MessageCount: 3}, {ChannelID: 20, MessageCount: 1}}
+	if len(channels) != len(want) || channels[0] != want[0] || channels[1] != want[1] {
+		t.Errorf("ChannelStats = %v, want %v", channels, want)
+	}
+
+	users, err := querier.UserStats(models.StatsFilter{From: &from})
+	if err != nil {
+		t.Fatalf("UserStats: %v", err)
+	}
+	if len(users) != 2 || users[0] != (models.UserStat{UserID: 100, MessageCount: 4}) {
+		t.Errorf("UserStats = %v", users)
+	}
+
+	days, err := querier.Activity(models.StatsFilter{From: &from}, stats.Daily)
+	if err != nil {
+		t.Fatalf("Activity: %v", err)
+	}
+	if len(days) != 2 || days[0].MessageCount != 3 || days[1].MessageCount != 2 {
+		t.Errorf("Activity = %v", days)
+	}
+
+	// A range inside the current period leaves the older file out.
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	count, err = querier.MessageCount(models.StatsFilter{From: &today})
+	if err != nil {
+		t.Fatalf("MessageCount: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("MessageCount since today = %d, want 2", count)
+	}
+}
@@ -0,0 +1,224 @@
+package stats
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"discord-bot/models"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Bucket selects the granularity of an activity histogram.
+type Bucket string
+
+const (
+	// Hourly groups messages by hour of day (local time), giving up to 24 buckets.
+	Hourly Bucket = "hourly"
+	// Daily groups messages by calendar day (local time).
+	Daily Bucket = "daily"
+)
+
+// Queryer is the part of *sql.DB and *sql.Conn a Querier needs.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Source visits the message tables covering [from, to). It calls fn once per group of tables
+// with a connection and a FROM expression reading them, e.g. "messages" or a UNION ALL
+// subquery over attached databases. The expression must expose channel_id, timestamp and the
+// owner's user column.
+type Source func(from, to *time.Time, fn func(db Queryer, table string) error) error
+
+// Querier runs statistics queries against the messages table of a base or plus database.
+// The two modes name the author column differently, so it is passed in by the owner.
+type Querier struct {
+	source     Source
+	userColumn string
+	conditions []string // Always applied, e.g. to skip purged rows
+}
+
+// New creates a Querier over the messages table of db. Any conditions are added to every
+// query, so owners can hide rows that should not count towards statistics.
+func New(db *sql.DB, userColumn string, conditions ...string) *Querier {
+	single := func(_, _ *time.Time, fn func(db Queryer, table string) error) error {
+		return fn(db, "messages")
+	}
+	return NewFromSource(single, userColumn, conditions...)
+}
+
+// NewFromSource creates a Querier over messages spread across several tables, such as the
+// period databases of a plus guild. Results of the groups the source visits are merged.
+func NewFromSource(source Source, userColumn string, conditions ...string) *Querier {
+	return &Querier{source: source, userColumn: userColumn, conditions: conditions}
+}
+
+// MessageCount returns the number of messages matching the filter.
+func (q *Querier) MessageCount(filter models.StatsFilter) (int64, error) {
+	var total int64
+	err := q.query(filter, "COUNT(*)", "", func(rows *sql.Rows) error {
+		var count int64
+		if err := rows.Scan(&count); err != nil {
+			return err
+		}
+		total += count
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+	return total, nil
+}
+
+// ChannelStats returns the number of messages per channel, busiest first.
+func (q *Querier) ChannelStats(filter models.StatsFilter) ([]models.ChannelStat, error) {
+	counts, err := q.countBy(filter, "channel_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channel stats: %w", err)
+	}
+	stats := make([]models.ChannelStat, 0, len(counts))
+	for _, c := range counts {
+		stats = append(stats, models.ChannelStat{ChannelID: c.id, MessageCount: c.count})
+	}
+	return stats, nil
+}
+
+// UserStats returns the number of messages per user, most active first.
+func (q *Querier) UserStats(filter models.StatsFilter) ([]models.UserStat, error) {
+	counts, err := q.countBy(filter, q.userColumn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user stats: %w", err)
+	}
+	stats := make([]models.UserStat, 0, len(counts))
+	for _, c := range counts {
+		stats = append(stats, models.UserStat{UserID: c.id, MessageCount: c.count})
+	}
+	return stats, nil
+}
+
+// Activity returns a histogram of messages per hour of day or per day, in bucket order.
+// Buckets without messages are omitted.
+func (q *Querier) Activity(filter models.StatsFilter, bucket Bucket) ([]models.ActivityStat, error) {
+	var format string
+	switch bucket {
+	case Hourly:
+		format = "%H"
+	case Daily:
+		format = "%Y-%m-%d"
+	default:
+		return nil, fmt.Errorf("unknown activity bucket %q", bucket)
+	}
+
+	counts := make(map[string]int64)
+	column := fmt.Sprintf("strftime('%s', timestamp, 'unixepoch', 'localtime')", format)
+	err := q.query(filter, column+", COUNT(*)", column, func(rows *sql.Rows) error {
+		var stat models.ActivityStat
+		if err := rows.Scan(&stat.Bucket, &stat.MessageCount); err != nil {
+			return err
+		}
+		counts[stat.Bucket] += stat.MessageCount
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s activity: %w", bucket, err)
+	}
+
+	stats := make([]models.ActivityStat, 0, len(counts))
+	for b, count := range counts {
+		stats = append(stats, models.ActivityStat{Bucket: b, MessageCount: count})
+	}
+	slices.SortFunc(stats, func(a, b models.ActivityStat) int {
+		return cmp.Compare(a.Bucket, b.Bucket)
+	})
+	return stats, nil
+}
+
+// idCount is the number of messages of one channel or user.
+type idCount struct {
+	id    int64
+	count int64
+}
+
+// countBy counts the messages per value of column over every group of the source, busiest
+// first, cut to the filter's limit after merging.
+func (q *Querier) countBy(filter models.StatsFilter, column string) ([]idCount, error) {
+	merged := make(map[int64]int64)
+	err := q.query(filter, "CAST("+column+" AS INTEGER), COUNT(*)", column, func(rows *sql.Rows) error {
+		var c idCount
+		if err := rows.Scan(&c.id, &c.count); err != nil {
+			return err
+		}
+		merged[c.id] += c.count
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]idCount, 0, len(merged))
+	for id, count := range merged {
+		counts = append(counts, idCount{id: id, count: count})
+	}
+	slices.SortFunc(counts, func(a, b idCount) int {
+		return cmp.Or(cmp.Compare(b.count, a.count), cmp.Compare(a.id, b.id))
+	})
+	if filter.Limit > 0 && len(counts) > filter.Limit {
+		counts = counts[:filter.Limit]
+	}
+	return counts, nil
+}
+
+// query runs "SELECT columns FROM table WHERE filter GROUP BY groupBy" over every group of the
+// source and calls scan for each row.
+func (q *Querier) query(filter models.StatsFilter, columns, groupBy string, scan func(rows *sql.Rows) error) error {
+	where, args := q.where(filter)
+	if groupBy != "" {
+		where += " GROUP BY " + groupBy
+	}
+	return q.source(filter.From, filter.To, func(db Queryer, table string) error {
+		rows, err := db.QueryContext(context.Background(), "SELECT "+columns+" FROM "+table+where, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			if err := scan(rows); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+}
+
+// where builds the WHERE clause for a filter.
+func (q *Querier) where(filter models.StatsFilter) (string, []any) {
+	conditions := append([]string{}, q.conditions...)
+	var args []any
+
+	if len(filter.ChannelIDs) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(filter.ChannelIDs)), ",")
+		conditions = append(conditions, "channel_id IN ("+placeholders+")")
+		for _, channelID := range filter.ChannelIDs {
+			args = append(args, channelID)
+		}
+	}
+	if filter.UserID != 0 {
+		conditions = append(conditions, q.userColumn+" = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, filter.From.Unix())
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, filter.To.Unix())
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
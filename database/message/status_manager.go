@@ -4,8 +4,10 @@ import (
 	"discord-bot/models"
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -17,17 +19,47 @@ type StatusManager struct {
 	status     *models.DBStatus
 }
 
-// NewStatusManager creates a new status manager.
+// NewStatusManager creates a new status manager. Databases recorded in an existing
+// status file are kept as inactive until they are registered again, so the history of
+// rotated files survives restarts.
 func NewStatusManager(statusFile string) *StatusManager {
-	return &StatusManager{
+	sm := &StatusManager{
 		statusFile: statusFile,
 		status: &models.DBStatus{
 			ActiveDatabases: make(map[string]*models.GuildDatabases),
 		},
 	}
+	sm.load()
+	return sm
+}
+
+// load reads the previous status file, if any.
+func (sm *StatusManager) load() {
+	data, err := os.ReadFile(sm.statusFile)
+	if err != nil {
+		return
+	}
+
+	var status models.DBStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		log.Printf("Failed to parse %s, starting with an empty status: %v", sm.statusFile, err)
+		return
+	}
+	for guildID, guild := range status.ActiveDatabases {
+		if guild == nil {
+			continue
+		}
+		for _, info := range []*models.DatabaseInfo{guild.Base, guild.Plus} {
+			if info != nil {
+				info.Status = "inactive"
+			}
+		}
+		sm.status.ActiveDatabases[guildID] = guild
+	}
 }
 
 // RegisterDB registers a database instance for a specific guild and mode.
+// A different path registered earlier for the same guild and mode is moved to the history.
 func (sm *StatusManager) RegisterDB(guildID, mode, dbPath string) {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
@@ -40,6 +72,15 @@ func (sm *StatusManager) RegisterDB(guildID, mode, dbPath string) {
 		DBPath: dbPath,
 		Status: "active",
 	}
+	if existing := sm.activeDB(guildID, mode); existing != nil {
+		dbInfo.History = existing.History
+		if existing.DBPath != dbPath && !hasHistory(dbInfo, existing.DBPath) && !strings.Contains(existing.DBPath, "$time_type") {
+			dbInfo.History = append(dbInfo.History, &models.DatabaseInfo{
+				DBPath: existing.DBPath,
+				Status: "archived",
+			})
+		}
+	}
 
 	switch mode {
 	case "base":
@@ -49,6 +90,87 @@ func (sm *StatusManager) RegisterDB(guildID, mode, dbPath string) {
 	}
 }
 
+// DBPaths returns the active and historical database paths of a guild and mode.
+func (sm *StatusManager) DBPaths(guildID, mode string) []string {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	info := sm.activeDB(guildID, mode)
+	if info == nil {
+		return nil
+	}
+	paths := []string{info.DBPath}
+	for _, h := range info.History {
+		paths = append(paths, h.DBPath)
+	}
+	return paths
+}
+
+// RegisterHistory records the files of previous periods for a guild and mode.
+// The active path of the mode is skipped, so it must be registered first.
+func (sm *StatusManager) RegisterHistory(guildID, mode string, dbPaths []string) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	active := sm.activeDB(guildID, mode)
+	if active == nil {
+		return
+	}
+	for _, dbPath := range dbPaths {
+		if dbPath == active.DBPath || hasHistory(active, dbPath) {
+			continue
+		}
+		active.History = append(active.History, &models.DatabaseInfo{
+			DBPath: dbPath,
+			Status: "archived",
+		})
+	}
+}
+
+// RotateDB marks the active database of a guild and mode as archived and registers dbPath as the new active one.
+func (sm *StatusManager) RotateDB(guildID, mode, dbPath string) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	active := sm.activeDB(guildID, mode)
+	if active == nil {
+		return
+	}
+	if active.DBPath != dbPath && !hasHistory(active, active.DBPath) {
+		active.History = append(active.History, &models.DatabaseInfo{
+			DBPath: active.DBPath,
+			Status: "archived",
+		})
+	}
+	active.DBPath = dbPath
+	active.Status = "active"
+}
+
+// activeDB returns the registered database of a guild and mode. The caller must hold the mutex.
+func (sm *StatusManager) activeDB(guildID, mode string) *models.DatabaseInfo {
+	guild, ok := sm.status.ActiveDatabases[guildID]
+	if !ok {
+		return nil
+	}
+	switch mode {
+	case "base":
+		return guild.Base
+	case "plus":
+		return guild.Plus
+	}
+	return nil
+}
+
+// hasHistory reports whether dbPath is already recorded as a previous period of info.
+func hasHistory(info *models.DatabaseInfo, dbPath string) bool {
+	for _, h := range info.History {
+		if h.DBPath == dbPath {
+			return true
+		}
+	}
+	return false
+}
+
 // Save commits the current database status to the JSON file.
 func (sm *StatusManager) Save() error {
 	sm.mutex.Lock()
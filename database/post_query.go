@@ -0,0 +1,228 @@
+package database
+
+import (
+	"database/sql"
+	"discord-bot/models"
+	"fmt"
+	"strings"
+)
+
+// postColumns is the column list shared by every post query.
+const postColumns = `db_id, thread_id, channel_id, title, author, author_id, content, tags,
+        message_count, timestamp, cover_image_url, total_reactions, unique_reactions, COALESCE(status, 'active') AS status`
+
+// qualifiedPostColumns selects the postColumns of a subquery aliased as p.
+const qualifiedPostColumns = `p.db_id, p.thread_id, p.channel_id, p.title, p.author, p.author_id, p.content, p.tags,
+        p.message_count, p.timestamp, p.cover_image_url, p.total_reactions, p.unique_reactions, p.status`
+
+// isSnowflake reports whether id looks like a Discord ID, so it is safe to embed in a table name.
+func isSnowflake(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ChannelTableName returns the per-channel table name used by the scanner.
+func ChannelTableName(channelID string) (string, error) {
+	if !isSnowflake(channelID) {
+		return "", fmt.Errorf("invalid channel ID %q", channelID)
+	}
+	return "channel_" + channelID, nil
+}
+
+// ListChannelTables returns the names of all channel_<id> tables in the database.
+func ListChannelTables(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name LIKE 'channel\_%' ESCAPE '\'`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channel tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		if isSnowflake(strings.TrimPrefix(name, "channel_")) {
+			tables = append(tables, name)
+		}
+	}
+	return tables, rows.Err()
+}
+
+// scanPost reads a row selected with postColumns into a Post.
+func scanPost(row interface{ Scan(...any) error }) (models.Post, error) {
+	var p models.Post
+	var title, author, authorID, content, tags, coverImageURL sql.NullString
+	err := row.Scan(
+		&p.DBID, &p.ThreadID, &p.ChannelID, &title, &author, &authorID, &content, &tags,
+		&p.MessageCount, &p.Timestamp, &coverImageURL, &p.TotalReactions, &p.UniqueReactions, &p.Status,
+	)
+	p.Title = title.String
+	p.Author = author.String
+	p.AuthorID = authorID.String
+	p.Content = content.String
+	p.Tags = tags.String
+	p.CoverImageURL = coverImageURL.String
+	return p, err
+}
+
+// GetPost retrieves a single post by thread ID from a channel table.
+// It returns nil, nil if the post or the table does not exist.
+func GetPost(db *sql.DB, tableName, threadID string) (*models.Post, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE thread_id = ?`, postColumns, tableName)
+	post, err := scanPost(db.QueryRow(query, threadID))
+	if err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no such table") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query post %s from table %s: %w", threadID, tableName, err)
+	}
+	return &post, nil
+}
+
+// QueryPosts lists posts across the selected channel tables, newest first (ties by thread ID),
+// and returns the matching page together with the total number of matches.
+// When q.Keyword is set the posts are searched and ordered by relevance instead (see SearchPosts).
+// Posts marked as deleted are never returned.
+func QueryPosts(db *sql.DB, q models.PostQuery) ([]models.Post, int, error) {
+	if strings.TrimSpace(q.Keyword) != "" {
+		results, total, err := SearchPosts(db, q)
+		if err != nil {
+			return nil, 0, err
+		}
+		posts := make([]models.Post, len(results))
+		for i, result := range results {
+			posts[i] = result.Post
+		}
+		return posts, total, nil
+	}
+
+	source, args, err := filteredPosts(db, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	if source == "" {
+		return []models.Post{}, 0, nil
+	}
+
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM "+source, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count posts: %w", err)
+	}
+
+	listQuery := fmt.Sprintf("SELECT %s FROM %s p ORDER BY timestamp DESC, thread_id DESC LIMIT ? OFFSET ?", qualifiedPostColumns, source)
+	rows, err := db.Query(listQuery, append(args, queryLimit(q.Limit), q.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	return posts, total, rows.Err()
+}
+
+// filteredPosts builds a subquery selecting every post that matches the filters of q,
+// ignoring the keyword and paging. It returns an empty source if there is no table to read.
+func filteredPosts(db *sql.DB, q models.PostQuery) (string, []any, error) {
+	var tables []string
+	if len(q.ChannelIDs) > 0 {
+		existing, err := ListChannelTables(db)
+		if err != nil {
+			return "", nil, err
+		}
+		known := make(map[string]bool, len(existing))
+		for _, name := range existing {
+			known[name] = true
+		}
+		for _, channelID := range q.ChannelIDs {
+			tableName, err := ChannelTableName(channelID)
+			if err != nil {
+				return "", nil, err
+			}
+			if known[tableName] {
+				tables = append(tables, tableName)
+			}
+		}
+	} else {
+		var err error
+		if tables, err = ListChannelTables(db); err != nil {
+			return "", nil, err
+		}
+	}
+	if len(tables) == 0 {
+		return "", nil, nil
+	}
+
+	selects := make([]string, len(tables))
+	for i, tableName := range tables {
+		selects[i] = fmt.Sprintf("SELECT %s FROM %s", postColumns, tableName)
+	}
+	source := "(" + strings.Join(selects, " UNION ALL ") + ")"
+
+	conditions := []string{"status != 'deleted'"}
+	var args []any
+	if q.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, q.Status)
+	}
+	if q.AuthorID != "" {
+		conditions = append(conditions, "author_id = ?")
+		args = append(args, q.AuthorID)
+	}
+	for _, tag := range q.Tags {
+		forms := append([]string{tag}, q.TagAliases[tag]...)
+		matches := make([]string, len(forms))
+		for i, form := range forms {
+			matches[i] = `(',' || COALESCE(tags, '') || ',') LIKE ? ESCAPE '\'`
+			args = append(args, "%,"+escapeLike(form)+",%")
+		}
+		conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+	}
+	if q.MinReactions > 0 {
+		conditions = append(conditions, "total_reactions >= ?")
+		args = append(args, q.MinReactions)
+	}
+	if q.StartTime > 0 {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, q.StartTime)
+	}
+	if q.EndTime > 0 {
+		conditions = append(conditions, "timestamp <= ?")
+		args = append(args, q.EndTime)
+	}
+	if q.Before != nil && *q.Before != (models.PostCursor{}) {
+		conditions = append(conditions, "(timestamp < ? OR (timestamp = ? AND thread_id < ?))")
+		args = append(args, q.Before.Timestamp, q.Before.Timestamp, q.Before.ThreadID)
+	}
+
+	return fmt.Sprintf("(SELECT * FROM %s WHERE %s)", source, strings.Join(conditions, " AND ")), args, nil
+}
+
+// queryLimit converts a page size into a LIMIT value; SQLite treats a negative LIMIT as unbounded.
+func queryLimit(limit int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return limit
+}
+
+// escapeLike escapes the LIKE wildcards in s using '\' as the escape character.
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
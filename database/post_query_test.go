@@ -0,0 +1,71 @@
+package database
+
+import (
+	"database/sql"
+	"discord-bot/models"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestQueryPostsMatchesTagAliases(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	const channelID = "100"
+	tableName, _ := ChannelTableName(channelID)
+	if err := CreateTableForChannel(db, tableName); err != nil {
+		t.Fatal(err)
+	}
+	// The scanner stores tag IDs, thread creation stores names.
+	posts := []models.Post{
+		{ThreadID: "1", ChannelID: channelID, Tags: "900,901", Timestamp: 1},
+		{ThreadID: "2", ChannelID: channelID, Tags: "Guide", Timestamp: 2},
+		{ThreadID: "3", ChannelID: channelID, Tags: "901", Timestamp: 3},
+	}
+	for _, post := range posts {
+		if err := InsertPost(db, post, tableName); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		tag     string
+		aliases []string
+		want    []string
+	}{
+		{"id with name alias", "900", []string{"Guide"}, []string{"2", "1"}},
+		{"name with id alias", "Guide", []string{"900"}, []string{"2", "1"}},
+		{"without aliases", "900", nil, []string{"1"}},
+		{"no match", "902", []string{"News"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := QueryPosts(db, models.PostQuery{
+				ChannelIDs: []string{channelID},
+				Tags:       []string{tt.tag},
+				TagAliases: map[string][]string{tt.tag: tt.aliases},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, post := range got {
+				ids = append(ids, post.ThreadID)
+			}
+			if total != len(tt.want) || len(ids) != len(tt.want) {
+				t.Fatalf("got %v (total %d), want %v", ids, total, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"database/sql"
+	"discord-bot/models"
+	"fmt"
+	"log"
+	"time"
+)
+
+// unseenActivePosts selects the active posts of a channel table missing from temp.scan_seen
+// that were created before the scan started, given as the only parameter.
+const unseenActivePosts = `status = 'active' AND timestamp < ? AND thread_id NOT IN (SELECT thread_id FROM temp.scan_seen)`
+
+// ReconcilePostStatus archives the active posts of a channel table whose threads were not in
+// seen, all in one transaction. Posts of seen threads are left to the scan's upserts, and posts
+// created since scanStart are kept because the scan listed the threads before they existed.
+// With dryRun nothing is written and the result also counts the posts the scan would revive or add.
+func ReconcilePostStatus(db *sql.DB, tableName string, seen map[string]bool, scanStart time.Time, dryRun bool) (*models.PostReconciliation, error) {
+	result := &models.PostReconciliation{DryRun: dryRun, Seen: len(seen)}
+
+	var exists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, tableName).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check table %s: %w", tableName, err)
+	}
+	if exists == 0 {
+		// Only a dry run gets here, a real scan creates the table first.
+		result.New = len(seen)
+		return result, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The seen IDs go into a temporary table, there can be more than SQLite allows as parameters.
+	if _, err := tx.Exec(`CREATE TEMP TABLE IF NOT EXISTS scan_seen (thread_id TEXT PRIMARY KEY)`); err != nil {
+		return nil, fmt.Errorf("failed to create seen threads table: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM temp.scan_seen`); err != nil {
+		return nil, fmt.Errorf("failed to clear seen threads table: %w", err)
+	}
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO temp.scan_seen (thread_id) VALUES (?)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement for seen threads: %w", err)
+	}
+	defer stmt.Close()
+	for threadID := range seen {
+		if _, err := stmt.Exec(threadID); err != nil {
+			return nil, fmt.Errorf("failed to record seen thread %s: %w", threadID, err)
+		}
+	}
+
+	rows, err := tx.Query(fmt.Sprintf(`SELECT thread_id FROM %s WHERE %s`, tableName, unseenActivePosts), scanStart.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unseen posts in table %s: %w", tableName, err)
+	}
+	for rows.Next() {
+		var threadID string
+		if err := rows.Scan(&threadID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan thread ID: %w", err)
+		}
+		result.Archived = append(result.Archived, threadID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query unseen posts in table %s: %w", tableName, err)
+	}
+	rows.Close()
+
+	if dryRun {
+		revived := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE status != 'active' AND thread_id IN (SELECT thread_id FROM temp.scan_seen)`, tableName)
+		if err := tx.QueryRow(revived).Scan(&result.Revived); err != nil {
+			return nil, fmt.Errorf("failed to count revived posts in table %s: %w", tableName, err)
+		}
+		newPosts := fmt.Sprintf(`SELECT COUNT(*) FROM temp.scan_seen WHERE thread_id NOT IN (SELECT thread_id FROM %s)`, tableName)
+		if err := tx.QueryRow(newPosts).Scan(&result.New); err != nil {
+			return nil, fmt.Errorf("failed to count new posts in table %s: %w", tableName, err)
+		}
+		// Rolling back also drops the temporary table.
+		return result, nil
+	}
+
+	if len(result.Archived) > 0 {
+		if _, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET status = 'archived' WHERE %s`, tableName, unseenActivePosts), scanStart.Unix()); err != nil {
+			return nil, fmt.Errorf("failed to archive unseen posts in table %s: %w", tableName, err)
+		}
+	}
+	if _, err := tx.Exec(`DROP TABLE temp.scan_seen`); err != nil {
+		return nil, fmt.Errorf("failed to drop seen threads table: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Printf("Reconciled table %s: %d threads seen, %d posts archived", tableName, len(seen), len(result.Archived))
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"database/sql"
+	"discord-bot/models"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// postStatuses returns the status of every post in a table by thread ID.
+func postStatuses(t *testing.T, db *sql.DB, tableName string) map[string]string {
+	t.Helper()
+	rows, err := db.Query("SELECT thread_id, status FROM " + tableName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	statuses := make(map[string]string)
+	for rows.Next() {
+		var threadID, status string
+		if err := rows.Scan(&threadID, &status); err != nil {
+			t.Fatal(err)
+		}
+		statuses[threadID] = status
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return statuses
+}
+
+func TestReconcilePostStatus(t *testing.T) {
+	scanStart := time.Unix(1_000_000, 0)
+	before, after := scanStart.Add(-time.Hour).Unix(), scanStart.Add(time.Minute).Unix()
+
+	// Thread IDs name the case each post covers.
+	posts := []struct {
+		threadID  string
+		status    string
+		timestamp int64
+	}{
+		{"seen", "active", before},
+		{"unseen", "active", before},
+		{"unseen-2", "active", before},
+		{"created-during-scan", "active", after},
+		{"archived-unseen", "archived", before},
+		{"archived-seen", "archived", before},
+		{"deleted-unseen", "deleted", before},
+	}
+	seen := map[string]bool{"seen": true, "archived-seen": true, "not-stored-yet": true}
+
+	tests := []struct {
+		name         string
+		dryRun       bool
+		wantStatuses map[string]string
+		wantResult   models.PostReconciliation
+	}{
+		{
+			name: "archives unseen active posts",
+			wantStatuses: map[string]string{
+				"seen":                "active",
+				"unseen":              "archived",
+				"unseen-2":            "archived",
+				"created-during-scan": "active",
+				"archived-unseen":     "archived",
+				"archived-seen":       "archived", // Revived by the scan's upsert, not here
+				"deleted-unseen":      "deleted",
+			},
+			wantResult: models.PostReconciliation{Seen: 3, Archived: []string{"unseen", "unseen-2"}},
+		},
+		{
+			name:       "dry run only counts",
+			dryRun:     true,
+			wantResult: models.PostReconciliation{DryRun: true, Seen: 3, Archived: []string{"unseen", "unseen-2"}, Revived: 1, New: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sql.Open("sqlite3", ":memory:")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			db.SetMaxOpenConns(1)
+
+			tableName, _ := ChannelTableName("100")
+			if err := CreateTableForChannel(db, tableName); err != nil {
+				t.Fatal(err)
+			}
+			for _, post := range posts {
+				if err := InsertPost(db, models.Post{ThreadID: post.threadID, ChannelID: "100", Timestamp: post.timestamp}, tableName); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := db.Exec("UPDATE "+tableName+" SET status = ? WHERE thread_id = ?", post.status, post.threadID); err != nil {
+					t.Fatal(err)
+				}
+			}
+			original := postStatuses(t, db, tableName)
+
+			result, err := ReconcilePostStatus(db, tableName, seen, scanStart, tt.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(result.Archived)
+			if result.DryRun != tt.wantResult.DryRun || result.Seen != tt.wantResult.Seen ||
+				!slices.Equal(result.Archived, tt.wantResult.Archived) ||
+				result.Revived != tt.wantResult.Revived || result.New != tt.wantResult.New {
+				t.Fatalf("got result %+v, want %+v", *result, tt.wantResult)
+			}
+
+			want := tt.wantStatuses
+			if tt.dryRun {
+				want = original
+			}
+			if got := postStatuses(t, db, tableName); !maps.Equal(got, want) {
+				t.Fatalf("got statuses %v, want %v", got, want)
+			}
+
+			// Nothing of the reconciliation outlives it, so a later run starts clean.
+			var leftover int
+			if err := db.QueryRow("SELECT COUNT(*) FROM temp.sqlite_master WHERE name = 'scan_seen'").Scan(&leftover); err != nil {
+				t.Fatal(err)
+			}
+			if leftover != 0 {
+				t.Fatal("temporary seen threads table was left behind")
+			}
+		})
+	}
+}
+
+func TestReconcilePostStatusDryRunWithoutTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	result, err := ReconcilePostStatus(db, "channel_100", map[string]bool{"a": true, "b": true}, time.Now(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.New != 2 || len(result.Archived) != 0 {
+		t.Fatalf("got result %+v, want 2 new posts", *result)
+	}
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Fatal("dry run created a table")
+	}
+}
@@ -0,0 +1,319 @@
+package database
+
+import (
+	"database/sql"
+	"discord-bot/models"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// searchIndexTable is the FTS5 index shared by every channel table of a scanning database.
+// Its rowid is the thread ID, so an entry can be replaced without scanning the index.
+//
+// FTS5 is only compiled into go-sqlite3 when building with `-tags sqlite_fts5`, see
+// doc/FULL_TEXT_SEARCH.md. Without it (or for terms shorter than a trigram) searches fall
+// back to LIKE matching, ordered by time.
+const searchIndexTable = "posts_fts"
+
+// minTrigramTerm is the shortest term the trigram tokenizer can match.
+const minTrigramTerm = 3
+
+// snippetContext is the number of characters kept on each side of a match in a fallback snippet.
+const snippetContext = 40
+
+// searchIndexes caches, per database file, whether the FTS5 index is usable. It is keyed by
+// path because the scanner and event handlers open a new *sql.DB for every run.
+var searchIndexes sync.Map // string -> bool
+
+var (
+	fts5Once      sync.Once
+	fts5Available bool
+)
+
+// FullTextSearchSupported reports whether the SQLite library was built with FTS5. The first
+// call logs a warning when it was not, so call it at startup to surface a default build.
+func FullTextSearchSupported() bool {
+	fts5Once.Do(func() {
+		db, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			log.Printf("Failed to probe for FTS5: %v", err)
+			return
+		}
+		defer db.Close()
+		if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5Available); err != nil {
+			log.Printf("Failed to probe for FTS5: %v", err)
+		}
+		if !fts5Available {
+			log.Printf("WARNING: SQLite was built without FTS5, /search falls back to slow LIKE matching without relevance ranking. Build with `go build -tags sqlite_fts5` to enable the full-text index.")
+		}
+	})
+	return fts5Available
+}
+
+// EnsureSearchIndex creates the FTS5 index if needed, filling it from the existing channel tables
+// on first creation. It reports whether the index can be used.
+func EnsureSearchIndex(db *sql.DB) bool {
+	path, err := databasePath(db)
+	if err != nil {
+		log.Printf("Full-text search index unavailable, falling back to LIKE search: %v", err)
+		return false
+	}
+	if available, ok := searchIndexes.Load(path); ok {
+		return available.(bool)
+	}
+
+	available, err := createSearchIndex(db)
+	if err != nil {
+		log.Printf("Full-text search index unavailable, falling back to LIKE search: %v", err)
+	}
+	// In-memory databases have no path and are not shared, so they are not cached.
+	if path != "" {
+		searchIndexes.Store(path, available)
+	}
+	return available
+}
+
+// databasePath returns the file of the main database of db, empty for an in-memory database.
+func databasePath(db *sql.DB) (string, error) {
+	var seq int
+	var name, file string
+	if err := db.QueryRow(`SELECT seq, name, file FROM pragma_database_list WHERE name = 'main'`).Scan(&seq, &name, &file); err != nil {
+		return "", fmt.Errorf("failed to resolve database path: %w", err)
+	}
+	return file, nil
+}
+
+// createSearchIndex creates and backfills the index. It returns false without an error
+// when the index could not be created.
+func createSearchIndex(db *sql.DB) (bool, error) {
+	// An index created by an FTS5 build exists in the file but cannot be queried without the module.
+	if !FullTextSearchSupported() {
+		return false, nil
+	}
+
+	var exists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, searchIndexTable).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check search index: %w", err)
+	}
+	if exists > 0 {
+		return true, nil
+	}
+
+	query := fmt.Sprintf(`CREATE VIRTUAL TABLE %s USING fts5(channel_id UNINDEXED, title, content, tags, tokenize = 'trigram')`, searchIndexTable)
+	if _, err := db.Exec(query); err != nil {
+		return false, fmt.Errorf("failed to create search index: %w", err)
+	}
+
+	tables, err := ListChannelTables(db)
+	if err != nil {
+		return true, err
+	}
+	for _, tableName := range tables {
+		backfill := fmt.Sprintf(`INSERT OR REPLACE INTO %s (rowid, channel_id, title, content, tags)
+            SELECT CAST(thread_id AS INTEGER), channel_id, COALESCE(title, ''), COALESCE(content, ''), COALESCE(tags, '') FROM %s`, searchIndexTable, tableName)
+		if _, err := db.Exec(backfill); err != nil {
+			return true, fmt.Errorf("failed to index posts from table %s: %w", tableName, err)
+		}
+	}
+	log.Printf("Full-text search index created for %d channel tables.", len(tables))
+	return true, nil
+}
+
+// indexPost adds or replaces a post in the search index. It is a no-op when the index is unavailable.
+func indexPost(db *sql.DB, post models.Post) error {
+	if !EnsureSearchIndex(db) {
+		return nil
+	}
+	rowID, err := strconv.ParseInt(post.ThreadID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid thread ID %q: %w", post.ThreadID, err)
+	}
+
+	query := fmt.Sprintf(`INSERT OR REPLACE INTO %s (rowid, channel_id, title, content, tags) VALUES (?, ?, ?, ?, ?)`, searchIndexTable)
+	if _, err := db.Exec(query, rowID, post.ChannelID, post.Title, post.Content, post.Tags); err != nil {
+		return fmt.Errorf("failed to index post %s: %w", post.ThreadID, err)
+	}
+	return nil
+}
+
+// SearchPosts finds posts whose title, content or tags contain every term of q.Keyword,
+// applying the other filters of q. Results are ranked by relevance when the full-text index
+// is available and ordered newest first otherwise, or when paging by q.Before.
+func SearchPosts(db *sql.DB, q models.PostQuery) ([]models.SearchResult, int, error) {
+	terms := strings.Fields(q.Keyword)
+	if len(terms) == 0 {
+		return nil, 0, fmt.Errorf("search keyword is empty")
+	}
+
+	source, args, err := filteredPosts(db, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	if source == "" {
+		return []models.SearchResult{}, 0, nil
+	}
+
+	if EnsureSearchIndex(db) && trigramSearchable(terms) {
+		return ftsSearch(db, source, args, terms, q)
+	}
+	return likeSearch(db, source, args, terms, q)
+}
+
+// ftsSearch ranks matches with bm25, weighting title over tags over content.
+func ftsSearch(db *sql.DB, source string, args []any, terms []string, q models.PostQuery) ([]models.SearchResult, int, error) {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	match := strings.Join(quoted, " ")
+
+	from := fmt.Sprintf("%s JOIN %s p ON %s.rowid = CAST(p.thread_id AS INTEGER) WHERE %s MATCH ?",
+		searchIndexTable, source, searchIndexTable, searchIndexTable)
+	args = append(args, match)
+
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM "+from, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	// Keyset pages follow the cursor order rather than relevance.
+	orderBy := fmt.Sprintf("bm25(%s, 0.0, 10.0, 1.0, 5.0)", searchIndexTable)
+	if q.Before != nil {
+		orderBy = "p.timestamp DESC, p.thread_id DESC"
+	}
+	listQuery := fmt.Sprintf(`SELECT %s, snippet(%s, -1, '**', '**', '…', 24) FROM %s
+        ORDER BY %s LIMIT ? OFFSET ?`,
+		qualifiedPostColumns, searchIndexTable, from, orderBy)
+	rows, err := db.Query(listQuery, append(args, queryLimit(q.Limit), q.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search posts: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var result models.SearchResult
+		post, err := scanPost(scanWithExtra(rows, &result.Snippet))
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Post = post
+		results = append(results, result)
+	}
+	return results, total, rows.Err()
+}
+
+// likeSearch matches every term with LIKE and builds the snippet in Go.
+func likeSearch(db *sql.DB, source string, args []any, terms []string, q models.PostQuery) ([]models.SearchResult, int, error) {
+	conditions := make([]string, len(terms))
+	for i, term := range terms {
+		conditions[i] = `(title LIKE ? ESCAPE '\' OR content LIKE ? ESCAPE '\' OR tags LIKE ? ESCAPE '\')`
+		pattern := "%" + escapeLike(term) + "%"
+		args = append(args, pattern, pattern, pattern)
+	}
+	from := fmt.Sprintf("%s p WHERE %s", source, strings.Join(conditions, " AND "))
+
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM "+from, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	listQuery := fmt.Sprintf("SELECT %s FROM %s ORDER BY timestamp DESC, thread_id DESC LIMIT ? OFFSET ?", qualifiedPostColumns, from)
+	rows, err := db.Query(listQuery, append(args, queryLimit(q.Limit), q.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search posts: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		results = append(results, models.SearchResult{Post: post, Snippet: makeSnippet(post, terms[0])})
+	}
+	return results, total, rows.Err()
+}
+
+// trigramSearchable reports whether every term is long enough for the trigram index.
+func trigramSearchable(terms []string) bool {
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) < minTrigramTerm {
+			return false
+		}
+	}
+	return true
+}
+
+// makeSnippet returns the text around the first occurrence of term, highlighted like FTS5 snippets.
+func makeSnippet(post models.Post, term string) string {
+	for _, text := range []string{post.Content, post.Title} {
+		runes := []rune(text)
+		lower := []rune(strings.ToLower(text))
+		needle := []rune(strings.ToLower(term))
+		if len(lower) != len(runes) {
+			// Lower-casing changed the length, fall back to a case-sensitive match.
+			lower = runes
+			needle = []rune(term)
+		}
+
+		idx := indexRunes(lower, needle)
+		if idx < 0 {
+			continue
+		}
+		start := max(idx-snippetContext, 0)
+		end := min(idx+len(needle)+snippetContext, len(runes))
+
+		var b strings.Builder
+		if start > 0 {
+			b.WriteString("…")
+		}
+		b.WriteString(string(runes[start:idx]))
+		b.WriteString("**" + string(runes[idx:idx+len(needle)]) + "**")
+		b.WriteString(string(runes[idx+len(needle) : end]))
+		if end < len(runes) {
+			b.WriteString("…")
+		}
+		return b.String()
+	}
+	return ""
+}
+
+// indexRunes returns the index of the first occurrence of needle in haystack, or -1.
+func indexRunes(haystack, needle []rune) int {
+	if len(needle) == 0 {
+		return -1
+	}
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// scanWithExtra lets scanPost read a row that carries extra columns after postColumns.
+func scanWithExtra(rows *sql.Rows, extra ...any) interface{ Scan(...any) error } {
+	return extraScanner{rows: rows, extra: extra}
+}
+
+type extraScanner struct {
+	rows  *sql.Rows
+	extra []any
+}
+
+func (s extraScanner) Scan(dest ...any) error {
+	return s.rows.Scan(append(dest, s.extra...)...)
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"database/sql"
+	"discord-bot/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// createScanTables creates the tables that make full scans resumable and keep their history.
+func createScanTables(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS scan_checkpoints (
+            guild_id TEXT NOT NULL,
+            channel_id TEXT NOT NULL,
+            scan_id TEXT NOT NULL,
+            before_ts TEXT NOT NULL,
+            pages_done INTEGER NOT NULL DEFAULT 0,
+            updated_at INTEGER NOT NULL,
+            PRIMARY KEY (guild_id, channel_id)
+        );`,
+		`CREATE TABLE IF NOT EXISTS scan_runs (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            scan_id TEXT NOT NULL,
+            guild_id TEXT NOT NULL,
+            channel_id TEXT NOT NULL,
+            full_scan INTEGER NOT NULL DEFAULT 0,
+            resumed_from TEXT NOT NULL DEFAULT '',
+            started_at INTEGER NOT NULL,
+            finished_at INTEGER NOT NULL DEFAULT 0,
+            posts_found INTEGER NOT NULL DEFAULT 0,
+            pages INTEGER NOT NULL DEFAULT 0,
+            status TEXT NOT NULL,
+            error TEXT NOT NULL DEFAULT ''
+        );`,
+		`CREATE INDEX IF NOT EXISTS idx_scan_runs_scan_id ON scan_runs(scan_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_scan_runs_channel ON scan_runs(guild_id, channel_id, started_at);`,
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetScanCheckpoint returns the checkpoint of an interrupted full scan of a channel, or nil if there is none.
+func GetScanCheckpoint(db *sql.DB, guildID, channelID string) (*models.ScanCheckpoint, error) {
+	checkpoint := models.ScanCheckpoint{GuildID: guildID, ChannelID: channelID}
+	var before string
+	err := db.QueryRow(`SELECT scan_id, before_ts, pages_done, updated_at FROM scan_checkpoints WHERE guild_id = ? AND channel_id = ?`, guildID, channelID).
+		Scan(&checkpoint.ScanID, &before, &checkpoint.PagesDone, &checkpoint.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scan checkpoint for channel %s: %w", channelID, err)
+	}
+	if checkpoint.Before, err = time.Parse(time.RFC3339Nano, before); err != nil {
+		return nil, fmt.Errorf("invalid scan checkpoint for channel %s: %w", channelID, err)
+	}
+	return &checkpoint, nil
+}
+
+// SaveScanCheckpoint stores the progress of a full scan in a channel, replacing the previous checkpoint.
+func SaveScanCheckpoint(db *sql.DB, checkpoint models.ScanCheckpoint) error {
+	_, err := db.Exec(`INSERT OR REPLACE INTO scan_checkpoints (guild_id, channel_id, scan_id, before_ts, pages_done, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		checkpoint.GuildID, checkpoint.ChannelID, checkpoint.ScanID, checkpoint.Before.Format(time.RFC3339Nano), checkpoint.PagesDone, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save scan checkpoint for channel %s: %w", checkpoint.ChannelID, err)
+	}
+	return nil
+}
+
+// DeleteScanCheckpoint removes the checkpoint of a channel once its full scan has finished.
+func DeleteScanCheckpoint(db *sql.DB, guildID, channelID string) error {
+	if _, err := db.Exec(`DELETE FROM scan_checkpoints WHERE guild_id = ? AND channel_id = ?`, guildID, channelID); err != nil {
+		return fmt.Errorf("failed to delete scan checkpoint for channel %s: %w", channelID, err)
+	}
+	return nil
+}
+
+// StartScanRun records that a partition scan started and returns the ID of the record.
+func StartScanRun(db *sql.DB, run models.ScanRun) (int64, error) {
+	result, err := db.Exec(`INSERT INTO scan_runs (scan_id, guild_id, channel_id, full_scan, resumed_from, started_at, status) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		run.ScanID, run.GuildID, run.ChannelID, run.FullScan, run.ResumedFrom, run.StartedAt, models.ScanRunRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record scan run for channel %s: %w", run.ChannelID, err)
+	}
+	return result.LastInsertId()
+}
+
+// FinishScanRun stores the outcome of a partition scan started with StartScanRun.
+func FinishScanRun(db *sql.DB, run models.ScanRun) error {
+	_, err := db.Exec(`UPDATE scan_runs SET finished_at = ?, posts_found = ?, pages = ?, status = ?, error = ? WHERE id = ?`,
+		run.FinishedAt, run.PostsFound, run.Pages, run.Status, run.Error, run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update scan run %d: %w", run.ID, err)
+	}
+	return nil
+}
+
+// InterruptScanRuns marks the runs of a guild still recorded as running as failed. Runs are
+// only left in that state when the bot stopped without finishing them.
+func InterruptScanRuns(db *sql.DB, guildID string) error {
+	_, err := db.Exec(`UPDATE scan_runs SET status = ?, error = 'interrupted' WHERE guild_id = ? AND status = ?`, models.ScanRunFailed, guildID, models.ScanRunRunning)
+	if err != nil {
+		return fmt.Errorf("failed to mark interrupted scan runs: %w", err)
+	}
+	return nil
+}
+
+// RecentScanRuns returns the latest partition runs of a guild, newest first.
+func RecentScanRuns(db *sql.DB, guildID string, limit int) ([]models.ScanRun, error) {
+	rows, err := db.Query(`SELECT id, scan_id, guild_id, channel_id, full_scan, resumed_from, started_at, finished_at, posts_found, pages, status, error
+        FROM scan_runs WHERE guild_id = ? ORDER BY id DESC LIMIT ?`, guildID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scan runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []models.ScanRun
+	for rows.Next() {
+		var run models.ScanRun
+		if err := rows.Scan(&run.ID, &run.ScanID, &run.GuildID, &run.ChannelID, &run.FullScan, &run.ResumedFrom,
+			&run.StartedAt, &run.FinishedAt, &run.PostsFound, &run.Pages, &run.Status, &run.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan scan run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
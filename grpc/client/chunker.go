@@ -0,0 +1,53 @@
+package client
+
+import (
+	pb "discord-bot/grpc/proto/gen/registry"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultMaxChunkSize 默认单块大小 (1 MiB)，远低于 gRPC 默认的 4 MiB 消息上限
+const defaultMaxChunkSize = 1 << 20
+
+// splitResponse 将响应体超过 maxChunkSize 的 ForwardResponse 拆分为有序的块。
+// 每个块携带相同的 request_id、状态码、头部和 streaming_info，
+// 并通过 response_stream_info 标明块索引、块大小、总大小以及是否为最后一块。
+// 未超过限制的响应原样返回。
+func splitResponse(resp *pb.ForwardResponse, maxChunkSize int) []*pb.ForwardResponse {
+	total := len(resp.Payload)
+	if maxChunkSize <= 0 || total <= maxChunkSize {
+		return []*pb.ForwardResponse{resp}
+	}
+
+	// 不带响应体的模板，避免每次克隆都复制完整的 payload
+	template := &pb.ForwardResponse{
+		RequestId:     resp.RequestId,
+		StatusCode:    resp.StatusCode,
+		Headers:       resp.Headers,
+		ErrorMessage:  resp.ErrorMessage,
+		StreamingInfo: resp.StreamingInfo,
+	}
+
+	count := (total + maxChunkSize - 1) / maxChunkSize
+	chunks := make([]*pb.ForwardResponse, 0, count)
+	for i := 0; i < count; i++ {
+		start := i * maxChunkSize
+		end := min(start+maxChunkSize, total)
+
+		chunk := proto.Clone(template).(*pb.ForwardResponse)
+		chunk.Payload = resp.Payload[start:end]
+		chunk.ResponseStreamInfo = &pb.ResponseStreamInfo{
+			IsStreamed:   true,
+			ChunkIndex:   int64(i),
+			IsFinalChunk: i == count-1,
+			ChunkSize:    int32(end - start),
+			TotalSize:    proto.Int64(int64(total)),
+		}
+		// 流结束标记只出现在最后一块上
+		if chunk.StreamingInfo != nil && i != count-1 {
+			chunk.StreamingInfo.IsStreamEnd = false
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"bytes"
+	"testing"
+
+	pb "discord-bot/grpc/proto/gen/registry"
+)
+
+func TestSplitResponse(t *testing.T) {
+	tests := []struct {
+		name         string
+		size         int
+		maxChunkSize int
+		wantChunks   int
+	}{
+		{"fits", 100, 100, 1},
+		{"one byte over", 101, 100, 2},
+		{"exact multiple", 300, 100, 3},
+		{"chunking disabled", 300, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := make([]byte, tt.size)
+			for i := range payload {
+				payload[i] = byte(i)
+			}
+			resp := &pb.ForwardResponse{
+				RequestId: "req",
+				Headers:   map[string]string{"grpc-status": "0"},
+				Payload:   payload,
+				StreamingInfo: &pb.StreamingInfo{
+					StreamType:  pb.StreamingInfo_SERVER_STREAMING,
+					IsStreamEnd: true,
+				},
+			}
+
+			chunks := splitResponse(resp, tt.maxChunkSize)
+			if len(chunks) != tt.wantChunks {
+				t.Fatalf("got %d chunks, want %d", len(chunks), tt.wantChunks)
+			}
+			if tt.wantChunks == 1 {
+				if chunks[0] != resp {
+					t.Fatal("a response that fits was not returned as is")
+				}
+				return
+			}
+
+			var body []byte
+			for i, chunk := range chunks {
+				info := chunk.GetResponseStreamInfo()
+				last := i == len(chunks)-1
+				if chunk.RequestId != "req" || chunk.Headers["grpc-status"] != "0" {
+					t.Fatalf("chunk %d lost the request ID or headers", i)
+				}
+				if !info.IsStreamed || info.ChunkIndex != int64(i) || info.IsFinalChunk != last {
+					t.Fatalf("chunk %d has stream info %v", i, info)
+				}
+				if int(info.ChunkSize) != len(chunk.Payload) || info.GetTotalSize() != int64(tt.size) {
+					t.Fatalf("chunk %d has sizes %d/%d for %d payload bytes", i, info.ChunkSize, info.GetTotalSize(), len(chunk.Payload))
+				}
+				if chunk.StreamingInfo.IsStreamEnd != last {
+					t.Fatalf("chunk %d has is_stream_end %v", i, chunk.StreamingInfo.IsStreamEnd)
+				}
+				body = append(body, chunk.Payload...)
+			}
+			if !bytes.Equal(body, payload) {
+				t.Fatal("chunks do not add up to the payload")
+			}
+			if !resp.StreamingInfo.IsStreamEnd {
+				t.Fatal("splitting modified the original response")
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TLSConfig 网关连接的 TLS 配置。
+// CertFile 与 KeyFile 同时设置时启用双向 TLS (mTLS)。
+type TLSConfig struct {
+	Enabled    bool
+	CAFile     string // 用于校验网关证书的 CA 文件 (PEM)，留空时使用系统根证书
+	CertFile   string // 客户端证书 (PEM)
+	KeyFile    string // 客户端私钥 (PEM)
+	ServerName string // 覆盖证书校验使用的服务器名称
+}
+
+// BuildTLSConfig 根据配置构建 tls.Config
+func (c TLSConfig) BuildTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.ServerName,
+	}
+
+	if c.CAFile != "" {
+		caPEM, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file %s: %w", c.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificates found in CA file %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, fmt.Errorf("both client certificate and key are required for mutual TLS")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// transportCredentials 返回连接使用的传输层凭证，未启用 TLS 时使用明文连接
+func (c TLSConfig) transportCredentials() (credentials.TransportCredentials, error) {
+	if !c.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+	tlsConfig, err := c.BuildTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// apiKeyCredentials 在每次 RPC 的元数据中附带 API 密钥
+type apiKeyCredentials struct {
+	apiKey     string
+	requireTLS bool
+}
+
+func (c apiKeyCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.apiKey}, nil
+}
+
+// RequireTransportSecurity 启用 TLS 时拒绝在明文连接上发送密钥
+func (c apiKeyCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
+
+// dialOptions 返回连接网关所需的拨号选项
+func (rc *RegistryClient) dialOptions() ([]grpc.DialOption, error) {
+	creds, err := rc.tlsConfig.transportCredentials()
+	if err != nil {
+		return nil, err
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if rc.apiKey != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(apiKeyCredentials{
+			apiKey:     rc.apiKey,
+			requireTLS: rc.tlsConfig.Enabled,
+		}))
+	}
+	return append(opts, rc.dialOpts...), nil
+}
@@ -0,0 +1,280 @@
+package client
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// testCA is a throwaway certificate authority that issues leaf certificates for a test.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue signs a leaf certificate for the given DNS names and returns it in PEM form.
+func (ca *testCA) issue(t *testing.T, usage x509.ExtKeyUsage, dnsNames ...string) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test leaf"},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes data to a file in dir and returns its path.
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// waitConnected reports whether rc registers with the gateway within timeout.
+func waitConnected(rc *RegistryClient, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if rc.IsConnected() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestBuildTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, x509.ExtKeyUsageClientAuth, "client")
+	certFile := writeFile(t, dir, "client.pem", certPEM)
+	keyFile := writeFile(t, dir, "client-key.pem", keyPEM)
+	badCAFile := writeFile(t, dir, "bad-ca.pem", []byte("not a certificate"))
+
+	tests := []struct {
+		name    string
+		cfg     TLSConfig
+		wantErr string
+	}{
+		{"missing CA file", TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}, "failed to read CA file"},
+		{"CA file without certificates", TLSConfig{CAFile: badCAFile}, "no valid certificates"},
+		{"cert without key", TLSConfig{CertFile: certFile}, "both client certificate and key"},
+		{"key without cert", TLSConfig{KeyFile: keyFile}, "both client certificate and key"},
+		{"cert and key swapped", TLSConfig{CertFile: keyFile, KeyFile: certFile}, "failed to load client certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cfg.BuildTLSConfig()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConnectOverTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := writeFile(t, dir, "ca.pem", ca.pem)
+	otherCAFile := writeFile(t, dir, "other-ca.pem", newTestCA(t).pem)
+	clientCert, clientKey := ca.issue(t, x509.ExtKeyUsageClientAuth, "client")
+	clientCertFile := writeFile(t, dir, "client.pem", clientCert)
+	clientKeyFile := writeFile(t, dir, "client-key.pem", clientKey)
+
+	// The client dials "bufnet", so a certificate for any other name needs ServerName.
+	tests := []struct {
+		name          string
+		serverNames   []string
+		requireClient bool
+		cfg           TLSConfig
+		wantConnected bool
+	}{
+		{
+			name:          "server TLS",
+			serverNames:   []string{"bufnet"},
+			cfg:           TLSConfig{Enabled: true, CAFile: caFile},
+			wantConnected: true,
+		},
+		{
+			name:        "untrusted CA",
+			serverNames: []string{"bufnet"},
+			cfg:         TLSConfig{Enabled: true, CAFile: otherCAFile},
+		},
+		{
+			name:        "server name mismatch",
+			serverNames: []string{"gateway.test"},
+			cfg:         TLSConfig{Enabled: true, CAFile: caFile},
+		},
+		{
+			name:          "server name override",
+			serverNames:   []string{"gateway.test"},
+			cfg:           TLSConfig{Enabled: true, CAFile: caFile, ServerName: "gateway.test"},
+			wantConnected: true,
+		},
+		{
+			name:          "client cert required but missing",
+			serverNames:   []string{"bufnet"},
+			requireClient: true,
+			cfg:           TLSConfig{Enabled: true, CAFile: caFile},
+		},
+		{
+			name:          "mutual TLS",
+			serverNames:   []string{"bufnet"},
+			requireClient: true,
+			cfg:           TLSConfig{Enabled: true, CAFile: caFile, CertFile: clientCertFile, KeyFile: clientKeyFile},
+			wantConnected: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certPEM, keyPEM := ca.issue(t, x509.ExtKeyUsageServerAuth, tt.serverNames...)
+			serverCert, err := tls.X509KeyPair(certPEM, keyPEM)
+			if err != nil {
+				t.Fatal(err)
+			}
+			serverTLS := &tls.Config{Certificates: []tls.Certificate{serverCert}}
+			if tt.requireClient {
+				serverTLS.ClientAuth = tls.RequireAndVerifyClientCert
+				serverTLS.ClientCAs = x509.NewCertPool()
+				serverTLS.ClientCAs.AddCert(ca.cert)
+			}
+
+			gateway, dialer := startGateway(t, grpc.Creds(credentials.NewTLS(serverTLS)))
+			rc := NewRegistryClient("passthrough:///bufnet", "secret", "test")
+			rc.SetTLSConfig(tt.cfg)
+			rc.WithDialOptions(dialer)
+			rc.Connect()
+			defer rc.Close()
+
+			timeout := 5 * time.Second
+			if !tt.wantConnected {
+				timeout = time.Second
+			}
+			if connected := waitConnected(rc, timeout); connected != tt.wantConnected {
+				t.Fatalf("connected = %v, want %v", connected, tt.wantConnected)
+			}
+			if !tt.wantConnected {
+				return
+			}
+
+			conn := gateway.accept(t)
+			if got := conn.register.GetApiKey(); got != "secret" {
+				t.Fatalf("registered with API key %q", got)
+			}
+			if got := conn.md.Get("authorization"); len(got) != 1 || got[0] != "Bearer secret" {
+				t.Fatalf("authorization metadata %v", got)
+			}
+		})
+	}
+}
+
+// syncBuffer is a bytes.Buffer that log output from several goroutines can share.
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func TestConnectWarnsAboutCleartextAPIKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		apiKey   string
+		wantWarn bool
+	}{
+		{"with API key", "secret", true},
+		{"without API key", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs syncBuffer
+			defer log.SetOutput(log.Writer())
+			log.SetOutput(&logs)
+
+			gateway, dialer := startGateway(t)
+			rc := NewRegistryClient("passthrough:///bufnet", tt.apiKey, "test")
+			rc.WithDialOptions(dialer)
+			if err := rc.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			gateway.accept(t)
+
+			if warned := strings.Contains(logs.String(), "明文"); warned != tt.wantWarn {
+				t.Fatalf("warned = %v, want %v; log:\n%s", warned, tt.wantWarn, logs.String())
+			}
+		})
+	}
+}
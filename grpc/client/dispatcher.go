@@ -0,0 +1,317 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	pb "discord-bot/grpc/proto/gen/registry"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// methodHandler 一个可被网关调用的本地方法，unary 与 stream 二者取其一
+type methodHandler struct {
+	impl   any
+	unary  grpc.MethodHandler
+	stream *grpc.StreamDesc
+}
+
+// ResponseSender 将一条转发响应发送回网关
+type ResponseSender func(*pb.ForwardResponse) error
+
+// Dispatcher 将网关转发的 ForwardRequest 按 method_path 分发到进程内注册的服务实现。
+// 它实现了 grpc.ServiceRegistrar，因此生成代码中的 RegisterXxxServer 可以直接使用。
+type Dispatcher struct {
+	mutex    sync.RWMutex
+	methods  map[string]*methodHandler // 完整方法路径，例如 /post.PostService/GetPost
+	services []string
+}
+
+// NewDispatcher 创建新的请求分发器
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		methods: make(map[string]*methodHandler),
+	}
+}
+
+// RegisterService 实现 grpc.ServiceRegistrar，登记服务的一元方法和服务端流方法
+func (d *Dispatcher) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, method := range desc.Methods {
+		path := fmt.Sprintf("/%s/%s", desc.ServiceName, method.MethodName)
+		d.methods[path] = &methodHandler{impl: impl, unary: method.Handler}
+	}
+	for i := range desc.Streams {
+		stream := &desc.Streams[i]
+		if stream.ClientStreams {
+			log.Printf("[gRPC] 客户端流方法 %s/%s 暂不支持通过网关调用", desc.ServiceName, stream.StreamName)
+			continue
+		}
+		path := fmt.Sprintf("/%s/%s", desc.ServiceName, stream.StreamName)
+		d.methods[path] = &methodHandler{impl: impl, stream: stream}
+	}
+	d.services = append(d.services, desc.ServiceName)
+}
+
+// Services 返回已注册的服务名称列表，用于向网关声明
+func (d *Dispatcher) Services() []string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return append([]string(nil), d.services...)
+}
+
+// Dispatch 执行转发请求并通过 send 返回响应。status_code 使用 gRPC 状态码 (0 表示成功)。
+// 一元方法只发送一条响应；服务端流方法每条消息发送一条响应，最后发送 is_stream_end 标记。
+func (d *Dispatcher) Dispatch(ctx context.Context, req *pb.ForwardRequest, send ResponseSender) error {
+	d.mutex.RLock()
+	handler, ok := d.methods[req.MethodPath]
+	d.mutex.RUnlock()
+	if !ok {
+		return send(errorResponse(req.RequestId, status.Errorf(codes.Unimplemented, "unknown method %s", req.MethodPath), nil))
+	}
+
+	if streamType := req.GetStreamingInfo().GetStreamType(); streamType == pb.StreamingInfo_CLIENT_STREAMING || streamType == pb.StreamingInfo_BIDIRECTIONAL_STREAMING {
+		return send(errorResponse(req.RequestId, status.Errorf(codes.Unimplemented, "%s streaming is not supported for %s", streamType, req.MethodPath), nil))
+	}
+
+	if req.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	ctx = metadata.NewIncomingContext(ctx, metadata.New(req.Headers))
+	ts := &forwardTransportStream{method: req.MethodPath}
+	ctx = grpc.NewContextWithServerTransportStream(ctx, ts)
+
+	if handler.stream != nil {
+		return d.dispatchStream(ctx, req, handler, ts, send)
+	}
+	return send(d.dispatchUnary(ctx, req, handler, ts))
+}
+
+// dispatchUnary 执行一元方法
+func (d *Dispatcher) dispatchUnary(ctx context.Context, req *pb.ForwardRequest, handler *methodHandler, ts *forwardTransportStream) *pb.ForwardResponse {
+	dec := func(v any) error {
+		return decodePayload(req.Payload, v)
+	}
+
+	type result struct {
+		reply any
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[gRPC] 处理 %s 时发生 panic: %v", req.MethodPath, r)
+				done <- result{err: status.Errorf(codes.Internal, "handler panic: %v", r)}
+			}
+		}()
+		reply, err := handler.unary(handler.impl, ctx, dec, nil)
+		done <- result{reply: reply, err: err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		return errorResponse(req.RequestId, status.FromContextError(ctx.Err()).Err(), ts.headers())
+	}
+
+	if res.err != nil {
+		return errorResponse(req.RequestId, res.err, ts.headers())
+	}
+
+	payload, err := encodePayload(res.reply)
+	if err != nil {
+		return errorResponse(req.RequestId, err, ts.headers())
+	}
+
+	headers := ts.headers()
+	headers["grpc-status"] = strconv.Itoa(int(codes.OK))
+	return &pb.ForwardResponse{
+		RequestId:  req.RequestId,
+		StatusCode: int32(codes.OK),
+		Headers:    headers,
+		Payload:    payload,
+	}
+}
+
+// dispatchStream 执行服务端流方法，handler 返回后发送带有最终状态的流结束标记
+func (d *Dispatcher) dispatchStream(ctx context.Context, req *pb.ForwardRequest, handler *methodHandler, ts *forwardTransportStream, send ResponseSender) error {
+	stream := &forwardServerStream{
+		ctx:  ctx,
+		req:  req,
+		ts:   ts,
+		send: send,
+	}
+
+	handlerErr := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[gRPC] 处理 %s 时发生 panic: %v", req.MethodPath, r)
+				err = status.Errorf(codes.Internal, "handler panic: %v", r)
+			}
+		}()
+		return handler.stream.Handler(handler.impl, stream)
+	}()
+	if handlerErr == nil && ctx.Err() != nil {
+		handlerErr = status.FromContextError(ctx.Err()).Err()
+	}
+
+	var end *pb.ForwardResponse
+	if handlerErr != nil {
+		end = errorResponse(req.RequestId, handlerErr, ts.headers())
+	} else {
+		headers := ts.headers()
+		headers["grpc-status"] = strconv.Itoa(int(codes.OK))
+		end = &pb.ForwardResponse{
+			RequestId:  req.RequestId,
+			StatusCode: int32(codes.OK),
+			Headers:    headers,
+		}
+	}
+	end.StreamingInfo = &pb.StreamingInfo{
+		StreamType:     pb.StreamingInfo_SERVER_STREAMING,
+		IsStreamEnd:    true,
+		SequenceNumber: stream.sequence,
+	}
+	return send(end)
+}
+
+// decodePayload 将请求体解码到 protobuf 消息
+func decodePayload(payload []byte, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "request type %T is not a protobuf message", v)
+	}
+	if err := proto.Unmarshal(payload, msg); err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to decode request payload: %v", err)
+	}
+	return nil
+}
+
+// encodePayload 将 protobuf 消息编码为响应体
+func encodePayload(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "response type %T is not a protobuf message", v)
+	}
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode response: %v", err)
+	}
+	return payload, nil
+}
+
+// errorResponse 根据 gRPC 错误构造失败响应
+func errorResponse(requestID string, err error, headers map[string]string) *pb.ForwardResponse {
+	st := status.Convert(err)
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	headers["grpc-status"] = strconv.Itoa(int(st.Code()))
+	headers["grpc-message"] = st.Message()
+	return &pb.ForwardResponse{
+		RequestId:    requestID,
+		StatusCode:   int32(st.Code()),
+		Headers:      headers,
+		ErrorMessage: st.Message(),
+	}
+}
+
+// forwardTransportStream 收集处理器通过 grpc.SetHeader / grpc.SetTrailer 设置的元数据
+type forwardTransportStream struct {
+	method string
+	mutex  sync.Mutex
+	md     metadata.MD
+}
+
+func (s *forwardTransportStream) Method() string { return s.method }
+
+func (s *forwardTransportStream) SetHeader(md metadata.MD) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.md = metadata.Join(s.md, md)
+	return nil
+}
+
+func (s *forwardTransportStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *forwardTransportStream) SetTrailer(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+// headers 将收集到的元数据展开为响应头部
+func (s *forwardTransportStream) headers() map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	headers := map[string]string{"content-type": "application/grpc+proto"}
+	for key, values := range s.md {
+		if len(values) > 0 {
+			headers[key] = values[len(values)-1]
+		}
+	}
+	return headers
+}
+
+// forwardServerStream 以 grpc.ServerStream 的形式向服务端流处理器提供转发请求，
+// 每次 SendMsg 都作为一条带序列号的 ForwardResponse 发回网关
+type forwardServerStream struct {
+	ctx      context.Context
+	req      *pb.ForwardRequest
+	ts       *forwardTransportStream
+	send     ResponseSender
+	received bool
+	sequence int64
+}
+
+func (s *forwardServerStream) SetHeader(md metadata.MD) error  { return s.ts.SetHeader(md) }
+func (s *forwardServerStream) SendHeader(md metadata.MD) error { return s.ts.SendHeader(md) }
+func (s *forwardServerStream) SetTrailer(md metadata.MD)       { s.ts.SetTrailer(md) }
+func (s *forwardServerStream) Context() context.Context        { return s.ctx }
+
+func (s *forwardServerStream) SendMsg(m any) error {
+	if err := s.ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	payload, err := encodePayload(m)
+	if err != nil {
+		return err
+	}
+	resp := &pb.ForwardResponse{
+		RequestId:  s.req.RequestId,
+		StatusCode: int32(codes.OK),
+		Payload:    payload,
+		StreamingInfo: &pb.StreamingInfo{
+			StreamType:     pb.StreamingInfo_SERVER_STREAMING,
+			SequenceNumber: s.sequence,
+		},
+	}
+	s.sequence++
+	return s.send(resp)
+}
+
+// RecvMsg 第一次调用返回请求体，之后返回 io.EOF
+func (s *forwardServerStream) RecvMsg(m any) error {
+	if s.received {
+		return io.EOF
+	}
+	s.received = true
+	return decodePayload(s.req.Payload, m)
+}
@@ -2,32 +2,59 @@ package client
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
+	mathrand "math/rand/v2"
+	"sync"
 	"time"
 
 	pb "discord-bot/grpc/proto/gen/registry"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	heartbeatInterval = 30 * time.Second
+	// heartbeatTimeout 超过该时间未收到服务端心跳时进入 StateDegraded，
+	// 超过两倍时认为连接已失效并主动断开重连
+	heartbeatTimeout = 3 * heartbeatInterval
+	maxBackoff       = 60 * time.Second
 )
 
 // RegistryClient 网关注册客户端
 type RegistryClient struct {
-	conn            *grpc.ClientConn
-	client          pb.RegistryServiceClient
-	stream          pb.RegistryService_EstablishConnectionClient
-	serverAddr      string
-	apiKey          string
-	clientName      string
-	connectionID    string
-	ctx             context.Context
-	cancel          context.CancelFunc
-	heartbeatTicker *time.Ticker
-	reconnectCount  int
-	maxReconnects   int
-	isConnected     bool
+	conn          *grpc.ClientConn
+	client        pb.RegistryServiceClient
+	stream        pb.RegistryService_EstablishConnectionClient
+	streamCancel  context.CancelFunc // 取消当前连接的流及其心跳
+	serverAddr    string
+	apiKey        string
+	clientName    string
+	ctx           context.Context
+	cancel        context.CancelFunc
+	maxReconnects int
+	dispatcher    *Dispatcher // 网关转发请求的本地分发器
+	maxChunkSize  int         // 单条响应体的最大字节数，超过后分块发送
+	subscriptions *subscriptions
+	tlsConfig     TLSConfig
+	dialOpts      []grpc.DialOption // 额外的拨号选项，例如测试时替换拨号器
+	sendMutex     sync.Mutex        // 保护 conn/stream，grpc 流的 Send 不允许并发调用
+
+	// 以下字段由 stateMutex 保护
+	stateMutex          sync.RWMutex
+	state               ConnectionState
+	stateSince          time.Time
+	stateHandlers       []StateChangeHandler
+	connectionID        string
+	reconnectCount      int
+	reconnecting        bool // 重连循环是否正在运行
+	reconnectPending    bool // 重连循环运行期间又有连接断开
+	lastServerHeartbeat time.Time
+	lastError           string
 }
 
 // NewRegistryClient 创建新的注册客户端
@@ -40,33 +67,69 @@ func NewRegistryClient(serverAddr, apiKey, clientName string) *RegistryClient {
 		ctx:           ctx,
 		cancel:        cancel,
 		maxReconnects: -1, // -1 表示无限重连
-		isConnected:   false,
+		dispatcher:    NewDispatcher(),
+		maxChunkSize:  defaultMaxChunkSize,
+		subscriptions: newSubscriptions(),
+		state:         StateIdle,
+		stateSince:    time.Now(),
 	}
 }
 
-// Connect 连接到网关服务器
+// Connect 连接到网关服务器。首次连接失败时返回错误，并在后台按退避策略继续重连。
 func (rc *RegistryClient) Connect() error {
+	if rc.State() == StateClosed {
+		return fmt.Errorf("client is closed")
+	}
+	if !rc.tlsConfig.Enabled && rc.apiKey != "" {
+		log.Printf("[gRPC] 警告: 未启用 TLS，API 密钥将以明文随注册消息和每次 RPC 的元数据发送到 %s，请设置 GRPC_TLS_ENABLED", rc.serverAddr)
+	}
+	if err := rc.connect(); err != nil {
+		rc.setLastError(err)
+		rc.closeConnection()
+		rc.scheduleReconnect()
+		return err
+	}
+	return nil
+}
+
+// connect 建立一次连接并发送注册消息，注册结果由服务端的 CONNECTED 状态消息确认
+func (rc *RegistryClient) connect() error {
+	rc.setState(StateConnecting)
+
+	opts, err := rc.dialOptions()
+	if err != nil {
+		return fmt.Errorf("failed to build connection credentials: %w", err)
+	}
+
 	// 建立 gRPC 连接
-	conn, err := grpc.NewClient(
-		rc.serverAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := grpc.NewClient(rc.serverAddr, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to connect to server: %w", err)
 	}
-
-	rc.conn = conn
-	rc.client = pb.NewRegistryServiceClient(conn)
+	client := pb.NewRegistryServiceClient(conn)
 
 	log.Printf("[gRPC] 已连接到网关服务器: %s", rc.serverAddr)
 
-	// 建立双向流连接
-	stream, err := rc.client.EstablishConnection(rc.ctx)
+	// 建立双向流连接，流的生命周期与本次连接绑定
+	streamCtx, streamCancel := context.WithCancel(rc.ctx)
+	stream, err := client.EstablishConnection(streamCtx)
 	if err != nil {
+		streamCancel()
+		conn.Close()
 		return fmt.Errorf("failed to establish connection stream: %w", err)
 	}
 
+	rc.sendMutex.Lock()
+	rc.conn = conn
+	rc.client = client
 	rc.stream = stream
+	rc.streamCancel = streamCancel
+	rc.sendMutex.Unlock()
+
+	rc.stateMutex.Lock()
+	rc.connectionID = ""
+	rc.lastServerHeartbeat = time.Time{}
+	rc.stateMutex.Unlock()
 
 	// 发送注册消息
 	if err := rc.register(); err != nil {
@@ -74,15 +137,12 @@ func (rc *RegistryClient) Connect() error {
 	}
 
 	// 启动接收消息的 goroutine
-	go rc.receiveMessages()
+	go rc.receiveMessages(stream)
 
 	// 启动心跳
-	rc.startHeartbeat()
-
-	rc.isConnected = true
-	rc.reconnectCount = 0 // 重置重连计数
-	log.Printf("[gRPC] 客户端 '%s' 注册成功", rc.clientName)
+	go rc.heartbeatLoop(streamCtx)
 
+	log.Printf("[gRPC] 客户端 '%s' 已发送注册请求", rc.clientName)
 	return nil
 }
 
@@ -92,13 +152,13 @@ func (rc *RegistryClient) register() error {
 		MessageType: &pb.ConnectionMessage_Register{
 			Register: &pb.ConnectionRegister{
 				ApiKey:       rc.apiKey,
-				Services:     []string{}, // 空实现，暂不提供服务
-				ConnectionId: "",         // 留空，等待服务端分配 UUID
+				Services:     rc.dispatcher.Services(),
+				ConnectionId: "", // 留空，等待服务端分配 UUID
 			},
 		},
 	}
 
-	if err := rc.stream.Send(registerMsg); err != nil {
+	if err := rc.send(registerMsg); err != nil {
 		return fmt.Errorf("failed to send register message: %w", err)
 	}
 
@@ -107,24 +167,41 @@ func (rc *RegistryClient) register() error {
 	return nil
 }
 
-// startHeartbeat 启动心跳
-func (rc *RegistryClient) startHeartbeat() {
-	rc.heartbeatTicker = time.NewTicker(30 * time.Second)
+// RegisterService 实现 grpc.ServiceRegistrar，登记可由网关转发调用的服务。
+// 必须在 Connect 之前调用，以便注册消息中包含这些服务。
+func (rc *RegistryClient) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	rc.dispatcher.RegisterService(desc, impl)
+	log.Printf("[gRPC] 已登记服务: %s", desc.ServiceName)
+}
 
-	go func() {
-		for {
-			select {
-			case <-rc.ctx.Done():
-				return
-			case <-rc.heartbeatTicker.C:
-				if err := rc.sendHeartbeat(); err != nil {
-					log.Printf("[gRPC] 发送心跳失败: %v", err)
-				}
+// send 串行化地向网关发送消息
+func (rc *RegistryClient) send(msg *pb.ConnectionMessage) error {
+	rc.sendMutex.Lock()
+	defer rc.sendMutex.Unlock()
+	if rc.stream == nil {
+		return fmt.Errorf("not connected to gateway")
+	}
+	return rc.stream.Send(msg)
+}
+
+// heartbeatLoop 定期发送心跳并检查服务端心跳，ctx 结束 (连接断开或关闭) 时退出
+func (rc *RegistryClient) heartbeatLoop(ctx context.Context) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	log.Printf("[gRPC] 心跳已启动 (间隔: %v)", heartbeatInterval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := rc.sendHeartbeat(); err != nil {
+				log.Printf("[gRPC] 发送心跳失败: %v", err)
 			}
+			rc.checkServerHeartbeat()
 		}
-	}()
-
-	log.Printf("[gRPC] 心跳已启动 (间隔: 30秒)")
+	}
 }
 
 // sendHeartbeat 发送心跳消息
@@ -133,12 +210,12 @@ func (rc *RegistryClient) sendHeartbeat() error {
 		MessageType: &pb.ConnectionMessage_Heartbeat{
 			Heartbeat: &pb.Heartbeat{
 				Timestamp:    time.Now().Unix(),
-				ConnectionId: rc.connectionID,
+				ConnectionId: rc.currentConnectionID(),
 			},
 		},
 	}
 
-	if err := rc.stream.Send(heartbeatMsg); err != nil {
+	if err := rc.send(heartbeatMsg); err != nil {
 		return fmt.Errorf("failed to send heartbeat: %w", err)
 	}
 
@@ -146,22 +223,67 @@ func (rc *RegistryClient) sendHeartbeat() error {
 	return nil
 }
 
-// receiveMessages 接收来自网关的消息
-func (rc *RegistryClient) receiveMessages() {
+// checkServerHeartbeat 检查服务端心跳是否超时。
+// 只有在本次连接收到过服务端心跳之后才会检查，以兼容不发送心跳的网关。
+func (rc *RegistryClient) checkServerHeartbeat() {
+	rc.stateMutex.RLock()
+	last := rc.lastServerHeartbeat
+	state := rc.state
+	rc.stateMutex.RUnlock()
+
+	if last.IsZero() || (state != StateRegistered && state != StateDegraded) {
+		return
+	}
+
+	missed := time.Since(last)
+	switch {
+	case missed > 2*heartbeatTimeout:
+		log.Printf("[gRPC] 已 %v 未收到服务端心跳，断开连接并重连", missed.Round(time.Second))
+		rc.setLastError(fmt.Errorf("no server heartbeat for %v", missed.Round(time.Second)))
+		rc.abortStream()
+	case missed > heartbeatTimeout:
+		log.Printf("[gRPC] 已 %v 未收到服务端心跳", missed.Round(time.Second))
+		rc.setState(StateDegraded)
+	}
+}
+
+// abortStream 取消当前的流，接收循环随后会触发重连
+func (rc *RegistryClient) abortStream() {
+	rc.sendMutex.Lock()
+	defer rc.sendMutex.Unlock()
+	if rc.streamCancel != nil {
+		rc.streamCancel()
+	}
+}
+
+// receiveMessages 接收来自网关的消息，流出错时触发重连
+func (rc *RegistryClient) receiveMessages(stream pb.RegistryService_EstablishConnectionClient) {
 	for {
-		msg, err := rc.stream.Recv()
+		msg, err := stream.Recv()
 		if err != nil {
+			// 客户端已关闭
+			if rc.ctx.Err() != nil {
+				return
+			}
+
+			// 连接已被替换，由新的接收循环负责
+			rc.sendMutex.Lock()
+			current := rc.stream == stream
+			rc.sendMutex.Unlock()
+			if !current {
+				return
+			}
+
 			if err == io.EOF {
 				log.Printf("[gRPC] 连接已关闭")
 			} else {
 				log.Printf("[gRPC] 接收消息错误: %v", err)
+				rc.setLastError(err)
 			}
 
-			// 标记为断开连接
-			rc.isConnected = false
-
-			// 触发重连
-			go rc.reconnect()
+			rc.closeConnection()
+			rc.setState(StateConnecting)
+			rc.scheduleReconnect()
 			return
 		}
 
@@ -173,126 +295,294 @@ func (rc *RegistryClient) receiveMessages() {
 func (rc *RegistryClient) handleMessage(msg *pb.ConnectionMessage) {
 	switch msg.MessageType.(type) {
 	case *pb.ConnectionMessage_Request:
-		// 空实现：暂不处理请求转发
-		req := msg.GetRequest()
-		log.Printf("[gRPC] 收到转发请求 (空实现): request_id=%s, method=%s",
-			req.RequestId, req.MethodPath)
+		// 转发请求在独立的 goroutine 中处理，避免阻塞接收循环
+		go rc.handleForwardRequest(msg.GetRequest())
 
 	case *pb.ConnectionMessage_Heartbeat:
 		// 收到服务器心跳
 		hb := msg.GetHeartbeat()
 		log.Printf("[gRPC] 收到服务器心跳: timestamp=%d", hb.Timestamp)
 
+		rc.stateMutex.Lock()
+		rc.lastServerHeartbeat = time.Now()
+		recovered := rc.state == StateDegraded
+		rc.stateMutex.Unlock()
+		if recovered {
+			rc.setState(StateRegistered)
+		}
+
 	case *pb.ConnectionMessage_Status:
 		// 连接状态消息
 		status := msg.GetStatus()
 		log.Printf("[gRPC] 连接状态: %s - %s", status.Status, status.Message)
 
-		// 如果是 CONNECTED 状态，保存服务端分配的连接ID
-		if status.Status == pb.ConnectionStatus_CONNECTED && status.ConnectionId != "" {
+		switch {
+		case status.Status == pb.ConnectionStatus_CONNECTED && status.ConnectionId != "":
+			// 保存服务端分配的连接ID，注册完成
+			rc.stateMutex.Lock()
 			rc.connectionID = status.ConnectionId
-			log.Printf("[gRPC] 已收到服务端分配的连接ID: %s", rc.connectionID)
+			rc.reconnectCount = 0 // 重置重连计数
+			rc.lastError = ""
+			rc.stateMutex.Unlock()
+			log.Printf("[gRPC] 已收到服务端分配的连接ID: %s", status.ConnectionId)
+			rc.setState(StateRegistered)
+
+			// 连接 (或重连) 完成后重新发送事件订阅
+			rc.resubscribe()
+
+		case status.Status == pb.ConnectionStatus_ERROR:
+			rc.setLastError(fmt.Errorf("gateway error: %s", status.Message))
 		}
 
 	case *pb.ConnectionMessage_Event:
 		// 事件消息
 		event := msg.GetEvent()
 		log.Printf("[gRPC] 收到事件: type=%s, id=%s", event.EventType, event.EventId)
+		rc.dispatchEvent(event)
 
 	default:
 		log.Printf("[gRPC] 收到未知消息类型")
 	}
 }
 
-// reconnect 重连到网关服务器
-func (rc *RegistryClient) reconnect() {
-	// 如果上下文已取消，不进行重连
-	if rc.ctx.Err() != nil {
-		log.Printf("[gRPC] 上下文已取消，停止重连")
-		return
+// SetTLSConfig 设置连接网关使用的 TLS 配置，必须在 Connect 之前调用
+func (rc *RegistryClient) SetTLSConfig(cfg TLSConfig) {
+	rc.tlsConfig = cfg
+}
+
+// WithDialOptions 追加额外的拨号选项，必须在 Connect 之前调用
+func (rc *RegistryClient) WithDialOptions(opts ...grpc.DialOption) {
+	rc.dialOpts = append(rc.dialOpts, opts...)
+}
+
+// SetMaxChunkSize 设置单条响应体的最大字节数，必须在 Connect 之前调用
+func (rc *RegistryClient) SetMaxChunkSize(size int) {
+	if size > 0 {
+		rc.maxChunkSize = size
 	}
+}
 
-	// 如果已连接，不重复重连
-	if rc.isConnected {
+// handleForwardRequest 将转发请求分发到本地服务并返回响应
+func (rc *RegistryClient) handleForwardRequest(req *pb.ForwardRequest) {
+	start := time.Now()
+	var final *pb.ForwardResponse
+	err := rc.dispatcher.Dispatch(rc.ctx, req, func(resp *pb.ForwardResponse) error {
+		final = resp
+		return rc.sendResponse(resp)
+	})
+	if err != nil {
+		log.Printf("[gRPC] 发送转发响应失败: request_id=%s, err=%v", req.RequestId, err)
 		return
 	}
 
-	rc.reconnectCount++
+	if final != nil && final.StatusCode != 0 {
+		log.Printf("[gRPC] 转发请求失败: request_id=%s, method=%s, status=%d, error=%s",
+			req.RequestId, req.MethodPath, final.StatusCode, final.ErrorMessage)
+	} else {
+		log.Printf("[gRPC] 转发请求完成: request_id=%s, method=%s, 耗时=%v",
+			req.RequestId, req.MethodPath, time.Since(start))
+	}
+}
 
-	// 检查是否超过最大重连次数
-	if rc.maxReconnects > 0 && rc.reconnectCount > rc.maxReconnects {
-		log.Printf("[gRPC] 已达到最大重连次数 (%d)，停止重连", rc.maxReconnects)
-		return
+// sendResponse 发送一条转发响应，响应体过大时拆分为多个有序块
+func (rc *RegistryClient) sendResponse(resp *pb.ForwardResponse) error {
+	for _, chunk := range splitResponse(resp, rc.maxChunkSize) {
+		msg := &pb.ConnectionMessage{
+			MessageType: &pb.ConnectionMessage_Response{
+				Response: chunk,
+			},
+		}
+		if err := rc.send(msg); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// 计算退避延迟：指数退避，最大 60 秒
-	backoff := time.Duration(1<<uint(rc.reconnectCount-1)) * time.Second
-	if backoff > 60*time.Second {
-		backoff = 60 * time.Second
+// PublishEvent 通过网关发布事件，payload 使用 protobuf 编码
+func (rc *RegistryClient) PublishEvent(eventType string, payload proto.Message, metadata map[string]string) error {
+	if !rc.IsConnected() {
+		return fmt.Errorf("not connected to gateway")
 	}
 
-	log.Printf("[gRPC] 第 %d 次重连尝试，等待 %v 后重连...", rc.reconnectCount, backoff)
+	data, err := proto.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode event payload: %w", err)
+	}
 
-	select {
-	case <-time.After(backoff):
-		// 继续重连
-	case <-rc.ctx.Done():
-		log.Printf("[gRPC] 上下文已取消，停止重连")
-		return
+	// 附带发布者名称，方便订阅方区分事件来源
+	meta := map[string]string{"source": rc.clientName}
+	for key, value := range metadata {
+		meta[key] = value
 	}
 
-	// 清理旧连接
-	if rc.heartbeatTicker != nil {
-		rc.heartbeatTicker.Stop()
+	eventMsg := &pb.ConnectionMessage{
+		MessageType: &pb.ConnectionMessage_Event{
+			Event: &pb.EventMessage{
+				EventId:     newEventID(),
+				EventType:   eventType,
+				PublisherId: rc.currentConnectionID(),
+				Payload:     data,
+				Timestamp:   time.Now().Unix(),
+				Metadata:    meta,
+			},
+		},
 	}
-	if rc.stream != nil {
-		rc.stream.CloseSend()
+
+	if err := rc.send(eventMsg); err != nil {
+		return fmt.Errorf("failed to send event %s: %w", eventType, err)
 	}
-	if rc.conn != nil {
-		rc.conn.Close()
+	return nil
+}
+
+// newEventID 生成随机的事件 ID
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
 	}
+	return hex.EncodeToString(b)
+}
 
-	// 重新连接
-	log.Printf("[gRPC] 正在尝试重新连接...")
-	if err := rc.Connect(); err != nil {
-		log.Printf("[gRPC] 重连失败: %v", err)
-		// 继续下一次重连尝试
-		go rc.reconnect()
-	} else {
-		log.Printf("[gRPC] 重连成功")
+// scheduleReconnect 启动重连循环，同一时间只会有一个重连循环运行
+func (rc *RegistryClient) scheduleReconnect() {
+	rc.stateMutex.Lock()
+	defer rc.stateMutex.Unlock()
+	if rc.state == StateClosed {
+		return
+	}
+	if rc.reconnecting {
+		rc.reconnectPending = true
+		return
 	}
+	rc.reconnecting = true
+	go rc.reconnectLoop()
 }
 
-// Close 关闭客户端连接
-func (rc *RegistryClient) Close() error {
-	log.Printf("[gRPC] 正在关闭客户端连接...")
+// reconnectLoop 按带抖动的指数退避重连到网关服务器，直到成功、关闭或达到最大重连次数
+func (rc *RegistryClient) reconnectLoop() {
+	for {
+		// 如果上下文已取消，不进行重连
+		if rc.ctx.Err() != nil {
+			log.Printf("[gRPC] 上下文已取消，停止重连")
+			rc.finishReconnect()
+			return
+		}
 
-	// 标记为未连接
-	rc.isConnected = false
+		rc.stateMutex.Lock()
+		rc.reconnectCount++
+		attempt := rc.reconnectCount
+		rc.reconnectPending = false
+		rc.stateMutex.Unlock()
 
-	// 取消上下文（这会停止重连尝试）
-	if rc.cancel != nil {
-		rc.cancel()
+		// 检查是否超过最大重连次数
+		if rc.maxReconnects > 0 && attempt > rc.maxReconnects {
+			log.Printf("[gRPC] 已达到最大重连次数 (%d)，停止重连", rc.maxReconnects)
+			rc.finishReconnect()
+			return
+		}
+
+		delay := reconnectDelay(attempt)
+		log.Printf("[gRPC] 第 %d 次重连尝试，等待 %v 后重连...", attempt, delay)
+
+		select {
+		case <-time.After(delay):
+			// 继续重连
+		case <-rc.ctx.Done():
+			log.Printf("[gRPC] 上下文已取消，停止重连")
+			rc.finishReconnect()
+			return
+		}
+
+		// 清理旧连接并重新连接
+		rc.closeConnection()
+		log.Printf("[gRPC] 正在尝试重新连接...")
+		if err := rc.connect(); err != nil {
+			log.Printf("[gRPC] 重连失败: %v", err)
+			rc.setLastError(err)
+			continue
+		}
+		log.Printf("[gRPC] 重连成功")
+
+		// 如果新连接在重连循环结束前已断开，继续重连
+		if !rc.finishReconnect() {
+			return
+		}
+	}
+}
+
+// finishReconnect 标记重连循环结束。若期间又有连接断开，返回 true 表示应继续重连。
+func (rc *RegistryClient) finishReconnect() bool {
+	rc.stateMutex.Lock()
+	defer rc.stateMutex.Unlock()
+	if rc.reconnectPending && rc.state != StateClosed && rc.ctx.Err() == nil {
+		rc.reconnectPending = false
+		return true
 	}
+	rc.reconnecting = false
+	rc.reconnectPending = false
+	return false
+}
 
-	// 停止心跳
-	if rc.heartbeatTicker != nil {
-		rc.heartbeatTicker.Stop()
+// reconnectDelay 计算第 attempt 次重连前的等待时间：
+// 指数退避，最大 60 秒，并在 [backoff/2, backoff) 范围内随机抖动，避免多个客户端同时重连
+func reconnectDelay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	backoff := maxBackoff
+	if attempt <= 6 {
+		backoff = time.Duration(1<<uint(attempt-1)) * time.Second
 	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	half := backoff / 2
+	return half + time.Duration(mathrand.Int64N(int64(half)+1))
+}
 
-	// 关闭流
+// closeConnection 关闭当前的流和连接
+func (rc *RegistryClient) closeConnection() error {
+	rc.sendMutex.Lock()
+	defer rc.sendMutex.Unlock()
+
+	if rc.streamCancel != nil {
+		rc.streamCancel()
+		rc.streamCancel = nil
+	}
 	if rc.stream != nil {
 		if err := rc.stream.CloseSend(); err != nil {
 			log.Printf("[gRPC] 关闭流失败: %v", err)
 		}
+		rc.stream = nil
 	}
 
-	// 关闭连接
+	var err error
 	if rc.conn != nil {
-		if err := rc.conn.Close(); err != nil {
+		if err = rc.conn.Close(); err != nil {
 			log.Printf("[gRPC] 关闭连接失败: %v", err)
-			return err
 		}
+		rc.conn = nil
+		rc.client = nil
+	}
+	return err
+}
+
+// Close 关闭客户端连接
+func (rc *RegistryClient) Close() error {
+	log.Printf("[gRPC] 正在关闭客户端连接...")
+
+	// 标记为已关闭（之后不再重连）
+	rc.setState(StateClosed)
+
+	// 取消上下文（这会停止重连尝试和心跳）
+	if rc.cancel != nil {
+		rc.cancel()
+	}
+
+	// 关闭流和连接
+	if err := rc.closeConnection(); err != nil {
+		return err
 	}
 
 	log.Printf("[gRPC] 客户端连接已关闭")
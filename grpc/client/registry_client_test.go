@@ -0,0 +1,206 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	postpb "discord-bot/grpc/proto/gen/post"
+	pb "discord-bot/grpc/proto/gen/registry"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeGateway accepts connections from a RegistryClient, confirms the registration and
+// hands the stream to the test.
+type fakeGateway struct {
+	pb.UnimplementedRegistryServiceServer
+	conns chan gatewayConn
+}
+
+// gatewayConn is one registered client as seen by the gateway.
+type gatewayConn struct {
+	register *pb.ConnectionRegister
+	md       metadata.MD // Metadata of the EstablishConnection call
+	stream   pb.RegistryService_EstablishConnectionServer
+}
+
+func (g *fakeGateway) EstablishConnection(stream pb.RegistryService_EstablishConnectionServer) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	err = stream.Send(&pb.ConnectionMessage{
+		MessageType: &pb.ConnectionMessage_Status{
+			Status: &pb.ConnectionStatus{ConnectionId: "conn-1", Status: pb.ConnectionStatus_CONNECTED},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	g.conns <- gatewayConn{register: msg.GetRegister(), md: md, stream: stream}
+	<-stream.Context().Done()
+	return nil
+}
+
+// startGateway serves a fakeGateway over an in-memory listener and returns it with the dial
+// option a RegistryClient needs to reach it.
+func startGateway(t *testing.T, opts ...grpc.ServerOption) (*fakeGateway, grpc.DialOption) {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	gateway := &fakeGateway{conns: make(chan gatewayConn, 1)}
+	srv := grpc.NewServer(opts...)
+	pb.RegisterRegistryServiceServer(srv, gateway)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	dialer := grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	})
+	return gateway, dialer
+}
+
+// accept waits for the client to register with the gateway.
+func (g *fakeGateway) accept(t *testing.T) gatewayConn {
+	t.Helper()
+	select {
+	case conn := <-g.conns:
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not connect to the gateway")
+		return gatewayConn{}
+	}
+}
+
+// fakePostService streams count posts whose content is contentSize bytes long.
+type fakePostService struct {
+	postpb.UnimplementedPostServiceServer
+	count       int
+	contentSize int
+}
+
+func (f fakePostService) StreamPosts(req *postpb.QueryPostsRequest, stream grpc.ServerStreamingServer[postpb.Post]) error {
+	for i := 0; i < f.count; i++ {
+		post := &postpb.Post{
+			Id:      string(rune('a' + i)),
+			Content: strings.Repeat(string(rune('a'+i)), f.contentSize),
+		}
+		if err := stream.Send(post); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestStreamPostsThroughGateway(t *testing.T) {
+	const (
+		posts        = 4
+		contentSize  = 2500
+		maxChunkSize = 1024
+	)
+
+	gateway, dialer := startGateway(t)
+	rc := NewRegistryClient("passthrough:///bufnet", "", "test")
+	rc.WithDialOptions(dialer)
+	rc.SetMaxChunkSize(maxChunkSize)
+	postpb.RegisterPostServiceServer(rc, fakePostService{count: posts, contentSize: contentSize})
+	if err := rc.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	conn := gateway.accept(t)
+	if services := conn.register.GetServices(); len(services) != 1 || services[0] != "post.PostService" {
+		t.Fatalf("registered services %v, want [post.PostService]", services)
+	}
+
+	payload, _ := proto.Marshal(&postpb.QueryPostsRequest{GuildId: "1"})
+	err := conn.stream.Send(&pb.ConnectionMessage{
+		MessageType: &pb.ConnectionMessage_Request{
+			Request: &pb.ForwardRequest{
+				RequestId:     "req-1",
+				MethodPath:    postpb.PostService_StreamPosts_FullMethodName,
+				Payload:       payload,
+				StreamingInfo: &pb.StreamingInfo{StreamType: pb.StreamingInfo_SERVER_STREAMING},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reassemble each streamed message from its chunks, checking they arrive in order.
+	var received []*postpb.Post
+	var body bytes.Buffer
+	var nextChunk int64
+	for {
+		msg, err := conn.stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp := msg.GetResponse()
+		if resp == nil {
+			continue
+		}
+		if resp.RequestId != "req-1" || resp.StatusCode != 0 {
+			t.Fatalf("unexpected response %v", resp)
+		}
+
+		if resp.GetStreamingInfo().GetIsStreamEnd() {
+			if seq := resp.StreamingInfo.SequenceNumber; seq != posts {
+				t.Fatalf("stream end has sequence %d, want %d", seq, posts)
+			}
+			break
+		}
+		if seq := resp.GetStreamingInfo().GetSequenceNumber(); seq != int64(len(received)) {
+			t.Fatalf("got sequence %d, want %d", seq, len(received))
+		}
+
+		info := resp.GetResponseStreamInfo()
+		if info == nil {
+			t.Fatalf("message %d of %d bytes was not chunked", len(received), len(resp.Payload))
+		}
+		if info.ChunkIndex != nextChunk {
+			t.Fatalf("got chunk %d, want %d", info.ChunkIndex, nextChunk)
+		}
+		if int(info.ChunkSize) != len(resp.Payload) || len(resp.Payload) > maxChunkSize {
+			t.Fatalf("chunk %d has size %d and %d payload bytes", info.ChunkIndex, info.ChunkSize, len(resp.Payload))
+		}
+		body.Write(resp.Payload)
+		nextChunk++
+		if !info.IsFinalChunk {
+			continue
+		}
+
+		if info.GetTotalSize() != int64(body.Len()) {
+			t.Fatalf("total_size %d, reassembled %d bytes", info.GetTotalSize(), body.Len())
+		}
+		post := &postpb.Post{}
+		if err := proto.Unmarshal(body.Bytes(), post); err != nil {
+			t.Fatalf("failed to decode message %d: %v", len(received), err)
+		}
+		received = append(received, post)
+		body.Reset()
+		nextChunk = 0
+	}
+
+	if body.Len() != 0 {
+		t.Fatalf("stream ended with %d bytes of an unfinished message", body.Len())
+	}
+	if len(received) != posts {
+		t.Fatalf("received %d posts, want %d", len(received), posts)
+	}
+	for i, post := range received {
+		want := string(rune('a' + i))
+		if post.Id != want || post.Content != strings.Repeat(want, contentSize) {
+			t.Fatalf("post %d is %q with %d bytes of content", i, post.Id, len(post.Content))
+		}
+	}
+}
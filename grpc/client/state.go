@@ -0,0 +1,127 @@
+package client
+
+import (
+	"log"
+	"time"
+)
+
+// ConnectionState 网关连接的生命周期状态
+type ConnectionState int
+
+const (
+	StateIdle       ConnectionState = iota // 尚未调用 Connect
+	StateConnecting                        // 正在建立连接或等待服务端分配连接 ID
+	StateRegistered                        // 已注册，连接正常
+	StateDegraded                          // 已注册，但未按时收到服务端心跳
+	StateClosed                            // 已调用 Close，不再重连
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateIdle:
+		return "idle"
+	case StateConnecting:
+		return "connecting"
+	case StateRegistered:
+		return "registered"
+	case StateDegraded:
+		return "degraded"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText 使状态在 JSON 中以名称输出
+func (s ConnectionState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// StateChangeHandler 在连接状态变化时调用
+type StateChangeHandler func(from, to ConnectionState)
+
+// Status 连接状态快照
+type Status struct {
+	ClientName          string          `json:"client_name"`
+	ServerAddress       string          `json:"server_address"`
+	State               ConnectionState `json:"state"`
+	StateSince          time.Time       `json:"state_since"`
+	ConnectionID        string          `json:"connection_id,omitempty"`
+	ReconnectCount      int             `json:"reconnect_count"`
+	LastServerHeartbeat time.Time       `json:"last_server_heartbeat"`
+	LastError           string          `json:"last_error,omitempty"`
+}
+
+// OnStateChange 登记状态变化回调，回调在独立的 goroutine 中执行
+func (rc *RegistryClient) OnStateChange(handler StateChangeHandler) {
+	rc.stateMutex.Lock()
+	defer rc.stateMutex.Unlock()
+	rc.stateHandlers = append(rc.stateHandlers, handler)
+}
+
+// Status 返回当前连接状态的快照
+func (rc *RegistryClient) Status() Status {
+	rc.stateMutex.RLock()
+	defer rc.stateMutex.RUnlock()
+	return Status{
+		ClientName:          rc.clientName,
+		ServerAddress:       rc.serverAddr,
+		State:               rc.state,
+		StateSince:          rc.stateSince,
+		ConnectionID:        rc.connectionID,
+		ReconnectCount:      rc.reconnectCount,
+		LastServerHeartbeat: rc.lastServerHeartbeat,
+		LastError:           rc.lastError,
+	}
+}
+
+// State 返回当前连接状态
+func (rc *RegistryClient) State() ConnectionState {
+	rc.stateMutex.RLock()
+	defer rc.stateMutex.RUnlock()
+	return rc.state
+}
+
+// IsConnected 报告连接是否已注册 (包括心跳异常但流仍然可用的情况)
+func (rc *RegistryClient) IsConnected() bool {
+	state := rc.State()
+	return state == StateRegistered || state == StateDegraded
+}
+
+// setState 切换状态并通知回调。进入 StateClosed 后不再离开该状态。
+func (rc *RegistryClient) setState(to ConnectionState) {
+	rc.stateMutex.Lock()
+	from := rc.state
+	if from == to || from == StateClosed {
+		rc.stateMutex.Unlock()
+		return
+	}
+	rc.state = to
+	rc.stateSince = time.Now()
+	handlers := append([]StateChangeHandler(nil), rc.stateHandlers...)
+	rc.stateMutex.Unlock()
+
+	log.Printf("[gRPC] 连接状态变化: %s -> %s", from, to)
+	for _, handler := range handlers {
+		go handler(from, to)
+	}
+}
+
+// setLastError 记录最近一次连接错误
+func (rc *RegistryClient) setLastError(err error) {
+	rc.stateMutex.Lock()
+	defer rc.stateMutex.Unlock()
+	if err != nil {
+		rc.lastError = err.Error()
+	} else {
+		rc.lastError = ""
+	}
+}
+
+// currentConnectionID 返回服务端分配的连接 ID
+func (rc *RegistryClient) currentConnectionID() string {
+	rc.stateMutex.RLock()
+	defer rc.stateMutex.RUnlock()
+	return rc.connectionID
+}
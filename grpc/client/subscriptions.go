@@ -0,0 +1,135 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	pb "discord-bot/grpc/proto/gen/registry"
+)
+
+// EventHandler 处理从网关收到的事件
+type EventHandler func(event *pb.EventMessage)
+
+// subscriptions 按事件类型保存本地处理器
+type subscriptions struct {
+	mutex    sync.RWMutex
+	handlers map[string][]EventHandler
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{
+		handlers: make(map[string][]EventHandler),
+	}
+}
+
+// add 登记处理器，返回该事件类型是否为首次订阅
+func (s *subscriptions) add(eventType string, handler EventHandler) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	first := len(s.handlers[eventType]) == 0
+	s.handlers[eventType] = append(s.handlers[eventType], handler)
+	return first
+}
+
+// remove 移除事件类型的所有处理器，返回该事件类型此前是否已订阅
+func (s *subscriptions) remove(eventType string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, ok := s.handlers[eventType]
+	delete(s.handlers, eventType)
+	return ok
+}
+
+// eventTypes 返回所有已订阅的事件类型
+func (s *subscriptions) eventTypes() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	types := make([]string, 0, len(s.handlers))
+	for eventType := range s.handlers {
+		types = append(types, eventType)
+	}
+	return types
+}
+
+// lookup 返回事件类型对应的处理器
+func (s *subscriptions) lookup(eventType string) []EventHandler {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]EventHandler(nil), s.handlers[eventType]...)
+}
+
+// Subscribe 为事件类型登记处理器。可以在 Connect 之前调用；
+// 连接 (以及每次重连) 成功后会自动向网关发送订阅请求。
+func (rc *RegistryClient) Subscribe(eventType string, handler EventHandler) {
+	first := rc.subscriptions.add(eventType, handler)
+	log.Printf("[gRPC] 已登记事件处理器: %s", eventType)
+
+	if first && rc.IsConnected() {
+		if err := rc.sendSubscription(pb.SubscriptionRequest_SUBSCRIBE, []string{eventType}); err != nil {
+			log.Printf("[gRPC] 发送订阅请求失败: %v", err)
+		}
+	}
+}
+
+// Unsubscribe 取消订阅事件类型并移除其所有处理器
+func (rc *RegistryClient) Unsubscribe(eventType string) {
+	if !rc.subscriptions.remove(eventType) {
+		return
+	}
+	if rc.IsConnected() {
+		if err := rc.sendSubscription(pb.SubscriptionRequest_UNSUBSCRIBE, []string{eventType}); err != nil {
+			log.Printf("[gRPC] 发送取消订阅请求失败: %v", err)
+		}
+	}
+}
+
+// resubscribe 将所有已登记的事件类型重新发送给网关，在收到连接 ID 后调用
+func (rc *RegistryClient) resubscribe() {
+	eventTypes := rc.subscriptions.eventTypes()
+	if len(eventTypes) == 0 {
+		return
+	}
+	if err := rc.sendSubscription(pb.SubscriptionRequest_SUBSCRIBE, eventTypes); err != nil {
+		log.Printf("[gRPC] 发送订阅请求失败: %v", err)
+		return
+	}
+	log.Printf("[gRPC] 已订阅事件: %v", eventTypes)
+}
+
+// sendSubscription 发送订阅/取消订阅请求
+func (rc *RegistryClient) sendSubscription(action pb.SubscriptionRequest_Action, eventTypes []string) error {
+	subscriptionMsg := &pb.ConnectionMessage{
+		MessageType: &pb.ConnectionMessage_Subscription{
+			Subscription: &pb.SubscriptionRequest{
+				Action:       action,
+				EventTypes:   eventTypes,
+				SubscriberId: rc.currentConnectionID(),
+			},
+		},
+	}
+	if err := rc.send(subscriptionMsg); err != nil {
+		return fmt.Errorf("failed to send subscription request: %w", err)
+	}
+	return nil
+}
+
+// dispatchEvent 将事件交给对应的处理器，每个处理器在独立的 goroutine 中运行
+func (rc *RegistryClient) dispatchEvent(event *pb.EventMessage) {
+	handlers := rc.subscriptions.lookup(event.EventType)
+	if len(handlers) == 0 {
+		log.Printf("[gRPC] 收到未订阅的事件: type=%s, id=%s", event.EventType, event.EventId)
+		return
+	}
+
+	for _, handler := range handlers {
+		go func(h EventHandler) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("[gRPC] 处理事件 %s 时发生 panic: %v", event.EventType, r)
+				}
+			}()
+			h(event)
+		}(handler)
+	}
+}
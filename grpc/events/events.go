@@ -0,0 +1,56 @@
+package events
+
+import (
+	"log"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// 发布到网关的事件类型
+const (
+	PostCreated    = "post.created"
+	PostDeleted    = "post.deleted"
+	MessageDeleted = "message.deleted"
+	MessageEdited  = "message.edited"
+	ScanFinished   = "scan.finished"
+)
+
+// 从网关订阅的事件类型
+const (
+	ScanRequested  = "scan.requested"
+	ExclusionAdded = "exclusion.added"
+)
+
+// Publisher 能够向网关发布事件的对象 (由 client.RegistryClient 实现)
+type Publisher interface {
+	PublishEvent(eventType string, payload proto.Message, metadata map[string]string) error
+}
+
+var (
+	publisher Publisher
+	mutex     sync.RWMutex
+)
+
+// InitPublisher 设置全局事件发布者。未设置时 Publish 不做任何事。
+func InitPublisher(p Publisher) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	publisher = p
+}
+
+// Publish 异步发布事件，不会阻塞调用方；发布失败只记录日志。
+func Publish(eventType string, payload proto.Message, metadata map[string]string) {
+	mutex.RLock()
+	p := publisher
+	mutex.RUnlock()
+	if p == nil {
+		return
+	}
+
+	go func() {
+		if err := p.PublishEvent(eventType, payload, metadata); err != nil {
+			log.Printf("[Events] 发布事件 %s 失败: %v", eventType, err)
+		}
+	}()
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"discord-bot/database/message/archive"
+	pb "discord-bot/grpc/proto/gen/attachment"
+	"discord-bot/models"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const attachmentChunkSize = 64 * 1024 // GetAttachment 每个分块的字节数
+
+// AttachmentServer 实现 AttachmentService，从 plus 模式的附件存档中读取附件
+type AttachmentServer struct {
+	pb.UnimplementedAttachmentServiceServer
+}
+
+// NewAttachmentServer 创建新的附件服务
+func NewAttachmentServer() *AttachmentServer {
+	return &AttachmentServer{}
+}
+
+// archiver 返回指定服务器的附件存档
+func (as *AttachmentServer) archiver(guildID string) (*archive.Archiver, error) {
+	a, ok := archive.Lookup(guildID)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "guild %s has no attachment archive", guildID)
+	}
+	return a, nil
+}
+
+// ListAttachments 列出某条消息已存档的附件
+func (as *AttachmentServer) ListAttachments(ctx context.Context, req *pb.ListAttachmentsRequest) (*pb.ListAttachmentsResponse, error) {
+	if req.GetGuildId() == "" || req.GetMessageId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "guild_id and message_id are required")
+	}
+	messageID, err := strconv.ParseInt(req.GetMessageId(), 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid message_id %q", req.GetMessageId())
+	}
+
+	a, err := as.archiver(req.GetGuildId())
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := a.Attachments(messageID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list attachments: %v", err)
+	}
+
+	resp := &pb.ListAttachmentsResponse{Attachments: make([]*pb.Attachment, 0, len(attachments))}
+	for _, attachment := range attachments {
+		resp.Attachments = append(resp.Attachments, attachmentToProto(attachment))
+	}
+	return resp, nil
+}
+
+// GetAttachment 按内容哈希分块返回附件数据，第一个分块携带附件信息
+func (as *AttachmentServer) GetAttachment(req *pb.GetAttachmentRequest, stream pb.AttachmentService_GetAttachmentServer) error {
+	if req.GetGuildId() == "" || req.GetHash() == "" {
+		return status.Error(codes.InvalidArgument, "guild_id and hash are required")
+	}
+
+	a, err := as.archiver(req.GetGuildId())
+	if err != nil {
+		return err
+	}
+	attachment, err := a.Get(req.GetHash())
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to look up attachment: %v", err)
+	}
+	if attachment == nil {
+		return status.Errorf(codes.NotFound, "attachment %s not found", req.GetHash())
+	}
+
+	file, err := a.Open(attachment.Hash)
+	if err != nil {
+		return status.Errorf(codes.NotFound, "attachment %s is missing from the store: %v", attachment.Hash, err)
+	}
+	defer file.Close()
+
+	chunk := &pb.AttachmentChunk{Attachment: attachmentToProto(*attachment)}
+	buf := make([]byte, attachmentChunkSize)
+	for {
+		n, readErr := file.Read(buf)
+		if n > 0 {
+			chunk.Data = buf[:n]
+			if err := stream.Send(chunk); err != nil {
+				return err
+			}
+			chunk = &pb.AttachmentChunk{}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return status.Errorf(codes.Internal, "failed to read attachment: %v", readErr)
+		}
+	}
+
+	// 空文件也要返回附件信息
+	if chunk.Attachment != nil {
+		return stream.Send(chunk)
+	}
+	return nil
+}
+
+// attachmentToProto 将存档记录转换为 protobuf 消息
+func attachmentToProto(attachment models.ArchivedAttachment) *pb.Attachment {
+	return &pb.Attachment{
+		MessageId:   strconv.FormatInt(attachment.MessageID, 10),
+		Url:         attachment.URL,
+		Hash:        attachment.Hash,
+		Filename:    attachment.Filename,
+		Size:        attachment.Size,
+		ContentType: attachment.ContentType,
+		ArchivedAt:  attachment.ArchivedAt,
+	}
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"discord-bot/database"
+	pb "discord-bot/grpc/proto/gen/post"
+	"discord-bot/models"
+
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	streamBatchSize = 200 // StreamPosts 每次从数据库读取的帖子数
+)
+
+// PostServer 实现 PostService，从扫描器写入的 channel_<id> 表中读取帖子
+type PostServer struct {
+	pb.UnimplementedPostServiceServer
+
+	mutex sync.Mutex
+	dbs   map[string]*sql.DB // guildID -> 扫描数据库连接
+}
+
+// NewPostServer 创建新的帖子服务
+func NewPostServer() *PostServer {
+	return &PostServer{
+		dbs: make(map[string]*sql.DB),
+	}
+}
+
+// guildDB 返回指定服务器的扫描数据库连接，首次使用时按 scanning_config 打开
+func (ps *PostServer) guildDB(guildID string) (*sql.DB, error) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	if db, ok := ps.dbs[guildID]; ok {
+		return db, nil
+	}
+
+	var guildConfig models.GuildConfig
+	if err := viper.UnmarshalKey("scanning_config."+guildID, &guildConfig); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load scanning config for guild %s: %v", guildID, err)
+	}
+	if guildConfig.DBPath == "" {
+		return nil, status.Errorf(codes.NotFound, "guild %s is not configured for scanning", guildID)
+	}
+
+	db, err := database.InitThreadDB(guildConfig.DBPath)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to open database for guild %s: %v", guildID, err)
+	}
+	ps.dbs[guildID] = db
+	return db, nil
+}
+
+// GetPost 根据 ID 获取单个帖子
+func (ps *PostServer) GetPost(ctx context.Context, req *pb.GetPostRequest) (*pb.Post, error) {
+	if req.GetId() == "" || req.GetGuildId() == "" || req.GetChannelId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id, guild_id and channel_id are required")
+	}
+
+	tableName, err := database.ChannelTableName(req.GetChannelId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	db, err := ps.guildDB(req.GetGuildId())
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := database.GetPost(db, tableName, req.GetId())
+	if err != nil {
+		log.Printf("[gRPC] GetPost 查询失败: %v", err)
+		return nil, status.Error(codes.Internal, "failed to query post")
+	}
+	if post == nil || post.Status == "deleted" {
+		return nil, status.Errorf(codes.NotFound, "post %s not found", req.GetId())
+	}
+
+	return toProtoPost(*post), nil
+}
+
+// QueryPosts 根据条件分页查询帖子，设置 keyword 时进行全文搜索并附带摘要
+func (ps *PostServer) QueryPosts(ctx context.Context, req *pb.QueryPostsRequest) (*pb.QueryPostsResponse, error) {
+	if req.GetGuildId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "guild_id is required")
+	}
+
+	query, err := postQueryFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := ps.guildDB(req.GetGuildId())
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Keyword != "" {
+		results, total, err := database.SearchPosts(db, query)
+		if err != nil {
+			log.Printf("[gRPC] QueryPosts 搜索失败: %v", err)
+			return nil, status.Error(codes.Internal, "failed to search posts")
+		}
+
+		resp := &pb.QueryPostsResponse{
+			Posts:      make([]*pb.Post, 0, len(results)),
+			TotalCount: int32(total),
+		}
+		for _, result := range results {
+			post := toProtoPost(result.Post)
+			post.Snippet = result.Snippet
+			resp.Posts = append(resp.Posts, post)
+		}
+		return resp, nil
+	}
+
+	posts, total, err := database.QueryPosts(db, query)
+	if err != nil {
+		log.Printf("[gRPC] QueryPosts 查询失败: %v", err)
+		return nil, status.Error(codes.Internal, "failed to query posts")
+	}
+
+	resp := &pb.QueryPostsResponse{
+		Posts:      make([]*pb.Post, 0, len(posts)),
+		TotalCount: int32(total),
+	}
+	for _, post := range posts {
+		resp.Posts = append(resp.Posts, toProtoPost(post))
+	}
+	return resp, nil
+}
+
+// StreamPosts 按查询条件以时间倒序流式返回所有匹配的帖子，分批读取数据库以限制内存占用
+func (ps *PostServer) StreamPosts(req *pb.QueryPostsRequest, stream pb.PostService_StreamPostsServer) error {
+	if req.GetGuildId() == "" {
+		return status.Error(codes.InvalidArgument, "guild_id is required")
+	}
+
+	query, err := postQueryFromRequest(req)
+	if err != nil {
+		return err
+	}
+
+	db, err := ps.guildDB(req.GetGuildId())
+	if err != nil {
+		return err
+	}
+
+	// 按 (timestamp, thread_id) 键集分页，避免 OFFSET 越往后扫描越多
+	ctx := stream.Context()
+	query.Limit = streamBatchSize
+	query.Offset = 0
+	query.Before = &models.PostCursor{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		posts, _, err := database.QueryPosts(db, query)
+		if err != nil {
+			log.Printf("[gRPC] StreamPosts 查询失败: %v", err)
+			return status.Error(codes.Internal, "failed to query posts")
+		}
+		for _, post := range posts {
+			if err := stream.Send(toProtoPost(post)); err != nil {
+				return err
+			}
+		}
+
+		if len(posts) < streamBatchSize {
+			return nil
+		}
+		last := posts[len(posts)-1]
+		query.Before = &models.PostCursor{Timestamp: last.Timestamp, ThreadID: last.ThreadID}
+	}
+}
+
+// Close 关闭所有已打开的数据库连接
+func (ps *PostServer) Close() error {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	for guildID, db := range ps.dbs {
+		if err := db.Close(); err != nil {
+			log.Printf("[gRPC] 关闭服务器 %s 的数据库失败: %v", guildID, err)
+		}
+		delete(ps.dbs, guildID)
+	}
+	return nil
+}
+
+// postQueryFromRequest 将请求转换为数据库查询条件，page_number 从 1 开始
+func postQueryFromRequest(req *pb.QueryPostsRequest) (models.PostQuery, error) {
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	pageNumber := int(req.GetPageNumber())
+	if pageNumber <= 0 {
+		pageNumber = 1
+	}
+
+	query := models.PostQuery{
+		AuthorID:  req.GetAuthorId(),
+		Keyword:   strings.TrimSpace(req.GetKeyword()),
+		Tags:      req.GetTags(),
+		StartTime: req.GetStartTime(),
+		EndTime:   req.GetEndTime(),
+		Limit:     pageSize,
+		Offset:    (pageNumber - 1) * pageSize,
+	}
+	if req.ChannelId != nil {
+		if _, err := database.ChannelTableName(req.GetChannelId()); err != nil {
+			return query, status.Error(codes.InvalidArgument, err.Error())
+		}
+		query.ChannelIDs = []string{req.GetChannelId()}
+	}
+	if query.StartTime > 0 && query.EndTime > 0 && query.StartTime > query.EndTime {
+		return query, status.Error(codes.InvalidArgument, fmt.Sprintf("start_time %d is after end_time %d", query.StartTime, query.EndTime))
+	}
+	return query, nil
+}
+
+// toProtoPost 将数据库模型转换为 protobuf 消息
+func toProtoPost(post models.Post) *pb.Post {
+	var tags []string
+	if post.Tags != "" {
+		tags = strings.Split(post.Tags, ",")
+	}
+	return &pb.Post{
+		Id:            post.ThreadID,
+		AuthorId:      post.AuthorID,
+		ChannelId:     post.ChannelID,
+		Title:         post.Title,
+		Content:       post.Content,
+		CreatedAt:     post.Timestamp,
+		Tags:          tags,
+		ReactionCount: int32(post.TotalReactions),
+		ReplyCount:    int32(post.MessageCount),
+		ImageUrl:      post.CoverImageURL,
+	}
+}
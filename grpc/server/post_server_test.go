@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"discord-bot/database"
+	pb "discord-bot/grpc/proto/gen/post"
+	"discord-bot/models"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testGuildID = "1"
+
+// startPostServer serves ps over an in-memory listener and returns a client for it.
+func startPostServer(t *testing.T, ps *PostServer) pb.PostServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterPostServiceServer(srv, ps)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewPostServiceClient(conn)
+}
+
+// seedPosts fills two channel tables with n posts. Every three posts share a timestamp, so
+// batch boundaries fall between posts of the same second.
+func seedPosts(t *testing.T, n int) *PostServer {
+	t.Helper()
+	db, err := database.InitThreadDB(filepath.Join(t.TempDir(), "threads.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := NewPostServer()
+	ps.dbs[testGuildID] = db
+	t.Cleanup(func() { ps.Close() })
+
+	for _, channelID := range []string{"100", "200"} {
+		tableName, _ := database.ChannelTableName(channelID)
+		if err := database.CreateTableForChannel(db, tableName); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < n; i++ {
+		channelID := "100"
+		if i%2 == 1 {
+			channelID = "200"
+		}
+		tableName, _ := database.ChannelTableName(channelID)
+		post := models.Post{
+			ThreadID:  fmt.Sprintf("%d", 1000+i),
+			ChannelID: channelID,
+			Title:     fmt.Sprintf("post %d", i),
+			Content:   []string{"even", "odd"}[i%2],
+			Timestamp: int64(i / 3),
+		}
+		if err := database.InsertPost(db, post, tableName); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ps
+}
+
+func TestStreamPostsPagesByKeyset(t *testing.T) {
+	const total = 2*streamBatchSize + 50
+	client := startPostServer(t, seedPosts(t, total))
+
+	tests := []struct {
+		name    string
+		keyword string
+		want    int
+	}{
+		{"all posts", "", total},
+		{"keyword", "odd", total / 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &pb.QueryPostsRequest{GuildId: testGuildID}
+			if tt.keyword != "" {
+				req.Keyword = &tt.keyword
+			}
+			stream, err := client.StreamPosts(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			seen := make(map[string]bool)
+			var prev *pb.Post
+			for {
+				post, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if seen[post.Id] {
+					t.Fatalf("post %s streamed twice", post.Id)
+				}
+				seen[post.Id] = true
+				if prev != nil && (post.CreatedAt > prev.CreatedAt || post.CreatedAt == prev.CreatedAt && post.Id > prev.Id) {
+					t.Fatalf("post %s (%d) streamed after %s (%d)", post.Id, post.CreatedAt, prev.Id, prev.CreatedAt)
+				}
+				prev = post
+			}
+			if len(seen) != tt.want {
+				t.Fatalf("streamed %d posts, want %d", len(seen), tt.want)
+			}
+		})
+	}
+}
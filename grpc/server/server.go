@@ -0,0 +1,62 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net"
+
+	attachmentpb "discord-bot/grpc/proto/gen/attachment"
+	pb "discord-bot/grpc/proto/gen/post"
+
+	"google.golang.org/grpc"
+)
+
+// Server 持有 bot 对外提供的 gRPC 服务实现，可独立监听端口，也可注册到网关反向连接
+type Server struct {
+	Post       *PostServer
+	Attachment *AttachmentServer
+	grpcServer *grpc.Server
+}
+
+// NewServer 创建服务集合
+func NewServer() *Server {
+	return &Server{
+		Post:       NewPostServer(),
+		Attachment: NewAttachmentServer(),
+	}
+}
+
+// RegisterServices 将所有服务注册到给定的 ServiceRegistrar (grpc.Server 或网关客户端)
+func (s *Server) RegisterServices(registrar grpc.ServiceRegistrar) {
+	pb.RegisterPostServiceServer(registrar, s.Post)
+	attachmentpb.RegisterAttachmentServiceServer(registrar, s.Attachment)
+}
+
+// Listen 在指定地址启动独立的 gRPC 监听
+func (s *Server) Listen(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	s.grpcServer = grpc.NewServer()
+	s.RegisterServices(s.grpcServer)
+
+	go func() {
+		if err := s.grpcServer.Serve(lis); err != nil {
+			log.Printf("[gRPC] 服务监听已停止: %v", err)
+		}
+	}()
+
+	log.Printf("[gRPC] PostService 和 AttachmentService 正在监听: %s", addr)
+	return nil
+}
+
+// Stop 停止监听并释放数据库连接
+func (s *Server) Stop() {
+	if s.grpcServer != nil {
+		s.grpcServer.GracefulStop()
+		log.Printf("[gRPC] 服务监听已关闭")
+	}
+	s.Post.Close()
+}
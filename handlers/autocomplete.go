@@ -1,41 +1,70 @@
 package handlers
 
 import (
+	"cmp"
 	"discord-bot/models"
 	"encoding/json"
 	"log"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/spf13/viper"
 )
 
+// maxAutocompleteChoices is the most choices Discord accepts in an autocomplete response.
+const maxAutocompleteChoices = 25
+
 // HandleAutocomplete handles all autocomplete interactions.
 func HandleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	data := i.ApplicationCommandData()
 	switch data.Name {
 	case "scan":
-		for _, opt := range i.ApplicationCommandData().Options {
-			if opt.Name == "guild_id" && opt.Focused {
+		options := data.Options
+		if len(options) > 0 && options[0].Type == discordgo.ApplicationCommandOptionSubCommand {
+			options = options[0].Options
+		}
+		for _, opt := range options {
+			if !opt.Focused {
+				continue
+			}
+			switch opt.Name {
+			case "guild_id":
 				handleGuildAutocomplete(s, i)
+			case "category":
+				handleCategoryAutocomplete(s, i, options, opt.StringValue())
+			}
+		}
+	case "recent_posts":
+		for _, opt := range data.Options {
+			if opt.Name == "tag" && opt.Focused {
+				handleTagAutocomplete(s, i, data.Options, opt.StringValue())
 			}
 		}
 	}
 }
 
-func handleGuildAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Read the config file
+// readScanningConfig reads the guilds configured for scanning.
+func readScanningConfig() (models.ScanningConfig, error) {
 	configFile, err := os.ReadFile("config/scanning_config.json")
 	if err != nil {
-		log.Printf("Error reading config file for autocomplete: %v", err)
-		return
+		return nil, err
 	}
 
 	var fileConfig models.ScanningFileConfig
 	if err := json.Unmarshal(configFile, &fileConfig); err != nil {
-		log.Printf("Error unmarshalling config file for autocomplete: %v", err)
+		return nil, err
+	}
+	return fileConfig.ScanningConfig, nil
+}
+
+func handleGuildAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	guilds, err := readScanningConfig()
+	if err != nil {
+		log.Printf("Error reading config file for autocomplete: %v", err)
 		return
 	}
-	guilds := fileConfig.ScanningConfig
 
 	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(guilds))
 	for _, guild := range guilds {
@@ -44,8 +73,94 @@ func handleGuildAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreat
 			Value: guild.GuildsID,
 		})
 	}
+	respondAutocomplete(s, i, choices)
+}
+
+// handleCategoryAutocomplete offers the configured categories matching the typed text, limited
+// to the guild picked in guild_id if any. Values are "<guild ID>:<key>", see parseCategoryValue.
+func handleCategoryAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption, typed string) {
+	guilds, err := readScanningConfig()
+	if err != nil {
+		log.Printf("Error reading config file for autocomplete: %v", err)
+		return
+	}
+	var guildID string
+	for _, opt := range options {
+		if opt.Name == "guild_id" {
+			guildID = opt.StringValue()
+		}
+	}
+
+	typed = strings.ToLower(typed)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for id, guild := range guilds {
+		if guildID != "" && id != guildID {
+			continue
+		}
+		for key, category := range guild.Data {
+			name := truncate(guild.Name+" / "+cmp.Or(category.CategoryName, key), 100)
+			if !strings.Contains(strings.ToLower(name), typed) && !strings.Contains(strings.ToLower(key), typed) {
+				continue
+			}
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  name,
+				Value: id + ":" + key,
+			})
+		}
+	}
+	slices.SortFunc(choices, func(a, b *discordgo.ApplicationCommandOptionChoice) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	if len(choices) > maxAutocompleteChoices {
+		choices = choices[:maxAutocompleteChoices]
+	}
+	respondAutocomplete(s, i, choices)
+}
+
+// handleTagAutocomplete offers the tags of the forum, or of the forums under the category, picked
+// in the channel option. Values are tag IDs; a category offers each tag name once.
+func handleTagAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption, typed string) {
+	var channelID string
+	for _, opt := range options {
+		if opt.Name == "channel" {
+			channelID, _ = opt.Value.(string)
+		}
+	}
+	if channelID == "" || i.GuildID == "" {
+		respondAutocomplete(s, i, nil)
+		return
+	}
+
+	channelIDs := []string{channelID}
+	if channel, err := s.State.Channel(channelID); err == nil && channel.Type == discordgo.ChannelTypeGuildCategory {
+		var guildConfig models.GuildConfig
+		if err := viper.UnmarshalKey("scanning_config."+i.GuildID, &guildConfig); err != nil {
+			log.Printf("Error reading scanning config for autocomplete: %v", err)
+		}
+		channelIDs = forumChannelsInCategory(s, i.GuildID, channelID, guildConfig)
+	}
+
+	typed = strings.ToLower(typed)
+	seen := make(map[string]bool)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, tag := range forumTags(s, channelIDs) {
+		if seen[tag.Name] || !strings.Contains(strings.ToLower(tag.Name), typed) {
+			continue
+		}
+		seen[tag.Name] = true
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  truncate(tag.Name, 100),
+			Value: tag.ID,
+		})
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+	}
+	respondAutocomplete(s, i, choices)
+}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+func respondAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate, choices []*discordgo.ApplicationCommandOptionChoice) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
 		Data: &discordgo.InteractionResponseData{
 			Choices: choices,
@@ -1,24 +1,46 @@
 package handlers
 
 import (
+	"cmp"
 	"discord-bot/models"
 	"discord-bot/scanner"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/spf13/viper"
 )
 
-// HandleScan handles the logic for the /scan command.
+// HandleScan handles the logic for the /scan command family.
 func HandleScan(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	options := i.ApplicationCommandData().Options
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		return
+	}
+	subcommand := data.Options[0]
+	switch subcommand.Name {
+	case "start":
+		handleScanStart(s, i, subcommand.Options)
+	case "status":
+		handleScanStatus(s, i)
+	case "cancel":
+		handleScanCancel(s, i)
+	}
+}
+
+// handleScanStart starts a scan and reports its progress in a follow-up message.
+func handleScanStart(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
 	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
 	for _, opt := range options {
 		optionMap[opt.Name] = opt
 	}
 
 	var scanType, guildID, scanMode string
+	var channelID, categoryGuildID, categoryKey string
+	var dryRun bool
 
 	if opt, ok := optionMap["type"]; ok {
 		scanType = opt.StringValue()
@@ -29,33 +51,38 @@ func HandleScan(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if opt, ok := optionMap["scan_mode"]; ok {
 		scanMode = opt.StringValue()
 	}
+	if opt, ok := optionMap["dry_run"]; ok {
+		dryRun = opt.BoolValue()
+	}
+	if opt, ok := optionMap["channel"]; ok {
+		channelID = opt.Value.(string)
+	}
+	if opt, ok := optionMap["category"]; ok {
+		categoryGuildID, categoryKey = parseCategoryValue(opt.StringValue(), cmp.Or(guildID, i.GuildID))
+	}
 
-	if scanType == "guild" && guildID == "" {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "Error: Guild ID is required for a guild-specific scan.",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
+	if scanType == "guild" && guildID == "" && channelID == "" && categoryKey == "" {
+		respondEphemeral(s, i, "Error: Guild ID is required for a guild-specific scan.")
+		return
+	}
+	if progress, ok := scanner.Progress(); ok && progress.FinishedAt.IsZero() {
+		respondEphemeral(s, i, "A scan is already running. Use `/scan cancel` to stop it.\n"+scanProgressContent(progress))
 		return
 	}
 
 	// Respond to the interaction immediately.
 	var initialResponse string
-	if scanType == "global" {
+	switch {
+	case channelID != "":
+		initialResponse = fmt.Sprintf("Received command to start a **%s** of <#%s>. Preparing to scan...", scanMode, channelID)
+	case categoryKey != "":
+		initialResponse = fmt.Sprintf("Received command to start a **%s** of category **%s**. Preparing to scan...", scanMode, categoryKey)
+	case scanType == "global":
 		initialResponse = fmt.Sprintf("Received command to start a **%s** global scan. Preparing to scan...", scanMode)
-	} else {
+	default:
 		initialResponse = fmt.Sprintf("Received command to start a **%s** for guild **%s**. Preparing to scan...", scanMode, guildID)
 	}
-
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: initialResponse,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
+	respondEphemeral(s, i, initialResponse)
 
 	// Run the scanning in a goroutine.
 	go func() {
@@ -69,53 +96,130 @@ func HandleScan(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		}
 
 		fullConfig := fileConfig.ScanningConfig
-		configToScan := make(models.ScanningConfig)
-		switch scanType {
-		case "guild":
-			guildConfig, ok := fullConfig[guildID]
+		var target *models.ScanTarget
+		switch {
+		case channelID != "":
+			target = &models.ScanTarget{GuildID: channelGuildID(i, channelID), ChannelID: channelID}
+		case categoryKey != "":
+			target = &models.ScanTarget{GuildID: categoryGuildID, Key: categoryKey}
+		case scanType == "guild", scanMode == "active_thread_scan" && guildID != "":
+			target = &models.ScanTarget{GuildID: guildID}
+		}
+
+		var targets []models.ScanTarget
+		if target != nil {
+			guildConfig, ok := fullConfig[target.GuildID]
 			if !ok {
-				log.Printf("Error: Guild ID %s not found in config.", guildID)
+				log.Printf("Error: Guild ID %s not found in config.", target.GuildID)
 				s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-					Content: fmt.Sprintf("Error: Guild ID %s not found in your configuration.", guildID),
+					Content: fmt.Sprintf("Error: Guild ID %s not found in your configuration.", target.GuildID),
 				})
 				return
 			}
-			configToScan[guildID] = guildConfig
-		case "global":
-			if scanMode == "active_thread_scan" && guildID != "" {
-				guildConfig, ok := fullConfig[guildID]
-				if !ok {
-					log.Printf("Error: Guild ID %s not found in config.", guildID)
-					s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-						Content: fmt.Sprintf("Error: Guild ID %s not found in your configuration.", guildID),
-					})
-					return
-				}
-				configToScan[guildID] = guildConfig
-			} else {
-				configToScan = fullConfig
+			if _, ok := guildConfig.Data[target.Key]; target.Key != "" && !ok {
+				s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+					Content: fmt.Sprintf("Error: Category %s is not configured for guild **%s**.", target.Key, guildConfig.Name),
+				})
+				return
 			}
+			targets = append(targets, *target)
 		}
 
 		isFullScan := (scanMode == "full_scan")
+		reporter := startScanProgress(s, i)
+		if dryRun {
+			log.Printf("Starting dry-run scan (isFullScan: %v, type: %s)", isFullScan, scanType)
+			results, err := scanner.DryRun(s, fullConfig, isFullScan, targets...)
+			content := dryRunSummary(scanMode, results)
+			if err != nil {
+				content = fmt.Sprintf("❌ Dry run (%s) did not finish: %v", scanMode, err)
+			}
+			reporter.finish(content)
+			return
+		}
+
 		log.Printf("Starting manual scan (isFullScan: %v, type: %s)", isFullScan, scanType)
-		scanner.StartScanning(s, configToScan, isFullScan)
+		err := scanner.StartScanning(s, fullConfig, isFullScan, targets...)
 		log.Printf("Manual scan finished (type: %s)", scanType)
 
-		// Send a followup message.
-		var followupContent string
-		if scanType == "global" {
-			followupContent = fmt.Sprintf("✅ Global scan (%s) has completed.", scanMode)
-		} else {
-			guildName := configToScan[guildID].Name
-			followupContent = fmt.Sprintf("✅ Scan (%s) for guild **%s** has completed.", scanMode, guildName)
+		// Replace the progress with the result of the scan.
+		if errors.Is(err, scanner.ErrScanInProgress) || errors.Is(err, scanner.ErrStopped) {
+			reporter.finish(fmt.Sprintf("❌ Scan (%s) did not start: %v", scanMode, err))
+			return
 		}
-		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: followupContent,
-		})
+		progress, _ := scanner.Progress()
+		reporter.finish(scanProgressContent(progress))
 	}()
 }
 
+// parseCategoryValue splits a category option picked from autocomplete, "<guild ID>:<key>".
+// A key typed without picking is looked up in defaultGuildID.
+func parseCategoryValue(value, defaultGuildID string) (guildID, key string) {
+	if guildID, key, found := strings.Cut(value, ":"); found {
+		return guildID, key
+	}
+	return defaultGuildID, value
+}
+
+// channelGuildID returns the guild of a channel option, which is the guild the command was used in.
+func channelGuildID(i *discordgo.InteractionCreate, channelID string) string {
+	if resolved := i.ApplicationCommandData().Resolved; resolved != nil {
+		if channel, ok := resolved.Channels[channelID]; ok && channel.GuildID != "" {
+			return channel.GuildID
+		}
+	}
+	return i.GuildID
+}
+
+// handleScanStatus shows the progress of the running scan, or the result of the last one.
+func handleScanStatus(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	progress, ok := scanner.Progress()
+	if !ok {
+		respondEphemeral(s, i, "No scan has run since the bot started.")
+		return
+	}
+	respondEphemeral(s, i, scanProgressContent(progress))
+}
+
+// handleScanCancel cancels the running scan. Its progress message reports when it stopped.
+func handleScanCancel(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	scanID, ok := scanner.Cancel()
+	if !ok {
+		respondEphemeral(s, i, "No scan is running.")
+		return
+	}
+	log.Printf("Scan %s cancelled by %s", scanID, interactionUserID(i))
+	respondEphemeral(s, i, fmt.Sprintf("🛑 Cancelling scan `%s`. Partitions in progress stop after their current request.", scanID))
+}
+
+// dryRunMaxChannels limits the channels listed in a dry-run summary to stay within a message.
+const dryRunMaxChannels = 15
+
+// dryRunSummary describes the status changes a scan would make, busiest channels first.
+func dryRunSummary(scanMode string, results []models.PostReconciliation) string {
+	slices.SortFunc(results, func(a, b models.PostReconciliation) int {
+		return cmp.Compare(len(b.Archived)+b.Revived+b.New, len(a.Archived)+a.Revived+a.New)
+	})
+
+	var archived, revived, added int
+	var lines []string
+	for _, r := range results {
+		archived += len(r.Archived)
+		revived += r.Revived
+		added += r.New
+		if len(lines) < dryRunMaxChannels && len(r.Archived)+r.Revived+r.New > 0 {
+			lines = append(lines, fmt.Sprintf("- <#%s>: %d to archive, %d to restore, %d new", r.ChannelID, len(r.Archived), r.Revived, r.New))
+		}
+	}
+
+	summary := fmt.Sprintf("🔍 Dry run (%s) checked %d channels: %d posts to archive, %d to restore, %d new.",
+		scanMode, len(results), archived, revived, added)
+	if len(lines) > 0 {
+		summary += "\n" + strings.Join(lines, "\n")
+	}
+	return summary
+}
+
 // HandlePing handles the logic for the /ping command.
 func HandlePing(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
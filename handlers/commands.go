@@ -21,6 +21,10 @@ func CommandDispatcher(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		"scan":         "admin",
 		"ping":         "guest",
 		"recent_posts": "guest",
+		"search":       "guest",
+		"stats":        "admin",
+		"history":      "admin",
+		"deleted":      "admin",
 	}
 
 	commandName := i.ApplicationCommandData().Name
@@ -44,6 +48,16 @@ func CommandDispatcher(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		HandleScan(s, i)
 	case "ping":
 		HandlePing(s, i)
+	case "recent_posts":
+		HandleRecentPosts(s, i)
+	case "search":
+		HandleSearch(s, i)
+	case "stats":
+		HandleStats(s, i)
+	case "history":
+		HandleHistory(s, i)
+	case "deleted":
+		HandleDeleted(s, i)
 	default:
 		// Optionally, send an error message for unknown commands.
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
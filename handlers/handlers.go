@@ -14,6 +14,7 @@ func Register(b *bot.Bot) {
 	if err := InitMessageCollector("config/message_listener.json"); err != nil {
 		log.Printf("Failed to initialize message collector: %v", err)
 	}
+	b.OnShutdown("message collector", CloseMessageCollector)
 
 	// Register event handlers
 	b.Session.AddHandler(InteractionCreate(b))
@@ -21,6 +22,7 @@ func Register(b *bot.Bot) {
 	b.Session.AddHandler(thread.ThreadDeleteHandler)
 	b.Session.AddHandler(MessageCreateHandler(b))
 	b.Session.AddHandler(MessageDeleteHandler(b))
+	b.Session.AddHandler(MessageDeleteBulkHandler(b))
 	b.Session.AddHandler(MessageUpdateHandler(b))
 	// b.Session.AddHandler(MemberAddHandler)
 	// b.Session.AddHandler(MemberRemoveHandler)
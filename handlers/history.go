@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"discord-bot/database/message/archive"
+	"discord-bot/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	historyMaxEdits       = 8   // Edit fields per embed, keeps the embed below Discord's 6000 character limit
+	historyContentLimit   = 600 // Runes of the original message shown in the description
+	historyEditLimit      = 200 // Runes of each side of an edit
+	historyAttachmentURLs = 3   // Attachment links shown before collapsing into a count
+	historyMaxFiles       = 4   // Archived files uploaded with the history view
+	historyMaxFileBytes   = 8 << 20
+	defaultDeletedDays    = 7
+	defaultDeletedLimit   = 10
+	deletedContentLimit   = 250
+	historyEmbedColor     = 0xFEE75C
+	deletedEmbedColor     = 0xED4245
+)
+
+// HandleHistory handles the "history" message context-menu command, showing the recorded
+// original content of a message and every edit made to it.
+func HandleHistory(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🚫 该命令只能在服务器中使用")
+		return
+	}
+	pdb, ok := GuildHistory(i.GuildID)
+	if !ok {
+		respondEphemeral(s, i, "🚫 该服务器未启用 plus 模式消息记录")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	messageID, err := strconv.ParseInt(data.TargetID, 10, 64)
+	if err != nil {
+		respondEphemeral(s, i, "🚫 无效的消息")
+		return
+	}
+
+	deferEphemeral(s, i)
+
+	original, err := pdb.GetMessage(messageID)
+	if err != nil {
+		log.Printf("Error loading message %d for history: %v", messageID, err)
+	}
+	edits, editErr := pdb.EditHistory(messageID)
+	if editErr != nil {
+		log.Printf("Error loading edit history of message %d: %v", messageID, editErr)
+	}
+
+	switch {
+	case err != nil && editErr != nil:
+		editDeferredResponse(s, i, "🚫 查询失败，请稍后重试", nil)
+	case original == nil && len(edits) == 0:
+		editDeferredResponse(s, i, "该消息没有任何记录。", nil)
+	default:
+		embed := historyEmbed(messageID, original, edits)
+		files := archivedFiles(i.GuildID, messageID, embed)
+		defer closeFiles(files)
+		editDeferredResponse(s, i, "", []*discordgo.MessageEmbed{embed}, files...)
+	}
+}
+
+// HandleDeleted handles the /deleted command, listing recently deleted messages in a channel.
+func HandleDeleted(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🚫 该命令只能在服务器中使用")
+		return
+	}
+	pdb, ok := GuildHistory(i.GuildID)
+	if !ok {
+		respondEphemeral(s, i, "🚫 该服务器未启用 plus 模式消息记录")
+		return
+	}
+
+	optionMap := commandOptions(i.ApplicationCommandData())
+	opt, ok := optionMap["channel"]
+	if !ok {
+		respondEphemeral(s, i, "🚫 请选择一个频道")
+		return
+	}
+	channelID, err := strconv.ParseInt(opt.Value.(string), 10, 64)
+	if err != nil {
+		respondEphemeral(s, i, "🚫 无效的频道")
+		return
+	}
+	limit := defaultDeletedLimit
+	if opt, ok := optionMap["limit"]; ok {
+		limit = int(opt.IntValue())
+	}
+	days := defaultDeletedDays
+	if opt, ok := optionMap["days"]; ok {
+		days = int(opt.IntValue())
+	}
+
+	deferEphemeral(s, i)
+
+	since := time.Now().AddDate(0, 0, -days)
+	deleted, err := pdb.DeletedMessages(channelID, &since, limit)
+	if err != nil {
+		log.Printf("Error loading deleted messages of channel %d: %v", channelID, err)
+		editDeferredResponse(s, i, "🚫 查询失败，请稍后重试", nil)
+		return
+	}
+	editDeferredResponse(s, i, "", []*discordgo.MessageEmbed{deletedEmbed(i.GuildID, channelID, days, deleted)})
+}
+
+// historyEmbed renders the original message followed by its most recent edits.
+func historyEmbed(messageID int64, original *models.Message, edits []models.MessageEdit) *discordgo.MessageEmbed {
+	var b strings.Builder
+	content, attachments := "", ""
+	if original != nil {
+		fmt.Fprintf(&b, "作者：<@%d>\n频道：<#%d>\n发送于：<t:%d:f>\n", original.UserID, original.ChannelID, original.Timestamp)
+		if original.ReplyToID != 0 {
+			fmt.Fprintf(&b, "回复：https://discord.com/channels/%d/%d/%d\n", original.GuildID, original.ChannelID, original.ReplyToID)
+		}
+		b.WriteString("\n")
+		content, attachments = original.MessageContent, original.Attachments
+	} else {
+		// The message predates recording; the first edit still captured what it looked like.
+		fmt.Fprintf(&b, "频道：<#%d>\n*原消息未被记录，以下为首次编辑前的内容*\n\n", edits[0].ChannelID)
+		content, attachments = edits[0].OriginalContent, edits[0].OriginalAttachments
+	}
+	b.WriteString("**原始内容**\n")
+	b.WriteString(quote(truncate(content, historyContentLimit)))
+	if links := attachmentLinks(attachments); links != "" {
+		b.WriteString("\n" + links)
+	}
+
+	shown := edits
+	if len(shown) > historyMaxEdits {
+		shown = shown[len(shown)-historyMaxEdits:]
+	}
+	first := len(edits) - len(shown) + 1
+
+	fields := make([]*discordgo.MessageEmbedField, 0, len(shown))
+	for n, edit := range shown {
+		value := fmt.Sprintf("<t:%d:f>\n**编辑前**\n%s\n**编辑后**\n%s",
+			edit.EditTimestamp,
+			quote(truncate(edit.OriginalContent, historyEditLimit)),
+			quote(truncate(edit.EditedContent, historyEditLimit)))
+		if before, after := attachmentCount(edit.OriginalAttachments), attachmentCount(edit.EditedAttachments); before != after {
+			value += fmt.Sprintf("\n📎 附件：%d → %d", before, after)
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("✏️ 第 %d 次编辑", first+n),
+			Value: value,
+		})
+	}
+
+	footer := fmt.Sprintf("消息 ID %d · 共 %d 次编辑", messageID, len(edits))
+	if len(shown) < len(edits) {
+		footer += fmt.Sprintf("，仅显示最近 %d 次", len(shown))
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "📝 消息编辑记录",
+		Description: b.String(),
+		Fields:      fields,
+		Color:       historyEmbedColor,
+		Footer:      &discordgo.MessageEmbedFooter{Text: footer},
+	}
+}
+
+// deletedEmbed renders deleted messages, newest first.
+func deletedEmbed(guildID string, channelID int64, days int, deleted []models.DeletedMessage) *discordgo.MessageEmbed {
+	var b strings.Builder
+	fmt.Fprintf(&b, "频道：<#%d> · 最近 %d 天\n", channelID, days)
+	if len(deleted) == 0 {
+		b.WriteString("\n该时间段内没有删除记录。")
+	}
+	for n, d := range deleted {
+		fmt.Fprintf(&b, "\n**%d.** 删除于 <t:%d:R>\n", n+1, d.DeletionTimestamp)
+		if d.Original == nil {
+			fmt.Fprintf(&b, "*消息 `%d` 未被记录*\n", d.MessageID)
+			continue
+		}
+		fmt.Fprintf(&b, "<@%d> 发送于 <t:%d:f>\n%s\n", d.Original.UserID, d.Original.Timestamp, quote(truncate(d.Original.MessageContent, deletedContentLimit)))
+		if count := attachmentCount(d.Original.Attachments); count > 0 {
+			fmt.Fprintf(&b, "📎 %d 个附件", count)
+			if archived := archivedCount(guildID, d.MessageID); archived > 0 {
+				fmt.Fprintf(&b, "（%d 个已存档，可通过 /history 查看）", archived)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "🗑️ 最近删除的消息",
+		Description: truncate(b.String(), 4096),
+		Color:       deletedEmbedColor,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+}
+
+// quote renders content as a Markdown block quote, marking empty content explicitly.
+func quote(content string) string {
+	if strings.TrimSpace(content) == "" {
+		return "> *（无文字内容）*"
+	}
+	return "> " + strings.ReplaceAll(content, "\n", "\n> ")
+}
+
+// attachmentURLs decodes the JSON array of attachment URLs stored with a message.
+func attachmentURLs(attachments string) []string {
+	if attachments == "" {
+		return nil
+	}
+	var urls []string
+	if err := json.Unmarshal([]byte(attachments), &urls); err != nil {
+		return nil
+	}
+	return urls
+}
+
+// attachmentCount returns the number of attachments stored with a message.
+func attachmentCount(attachments string) int {
+	return len(attachmentURLs(attachments))
+}
+
+// attachmentLinks renders the first few attachments as links and counts the rest.
+func attachmentLinks(attachments string) string {
+	urls := attachmentURLs(attachments)
+	if len(urls) == 0 {
+		return ""
+	}
+	links := make([]string, 0, historyAttachmentURLs)
+	for n, url := range urls {
+		if n == historyAttachmentURLs {
+			break
+		}
+		links = append(links, fmt.Sprintf("[附件 %d](%s)", n+1, url))
+	}
+	line := "📎 " + strings.Join(links, " · ")
+	if len(urls) > historyAttachmentURLs {
+		line += fmt.Sprintf(" 等 %d 个附件", len(urls))
+	}
+	return line
+}
+
+// archivedFiles lists the archived attachments of a message in the embed and opens the first
+// few for upload, since their CDN URLs may no longer work. The caller must close the files.
+func archivedFiles(guildID string, messageID int64, embed *discordgo.MessageEmbed) []*discordgo.File {
+	a, ok := archive.Lookup(guildID)
+	if !ok {
+		return nil
+	}
+	attachments, err := a.Attachments(messageID)
+	if err != nil {
+		log.Printf("Error loading archived attachments of message %d: %v", messageID, err)
+		return nil
+	}
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	var lines []string
+	var files []*discordgo.File
+	var total int64
+	for _, attachment := range attachments {
+		lines = append(lines, fmt.Sprintf("`%s` · %s · %s", truncate(attachment.Filename, 40), formatBytes(attachment.Size), attachment.ContentType))
+		if len(files) == historyMaxFiles || total+attachment.Size > historyMaxFileBytes {
+			continue
+		}
+		file, err := a.Open(attachment.Hash)
+		if err != nil {
+			log.Printf("Error opening archived attachment %s: %v", attachment.Hash, err)
+			continue
+		}
+		files = append(files, &discordgo.File{Name: attachment.Filename, ContentType: attachment.ContentType, Reader: file})
+		total += attachment.Size
+	}
+
+	value := strings.Join(lines, "\n")
+	if len(files) < len(attachments) {
+		value += fmt.Sprintf("\n*仅上传了 %d 个文件*", len(files))
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  "📦 已存档附件",
+		Value: truncate(value, 1024),
+	})
+	return files
+}
+
+// archivedCount returns how many attachments of a message are in the guild's archive.
+func archivedCount(guildID string, messageID int64) int {
+	a, ok := archive.Lookup(guildID)
+	if !ok {
+		return 0
+	}
+	attachments, err := a.Attachments(messageID)
+	if err != nil {
+		log.Printf("Error loading archived attachments of message %d: %v", messageID, err)
+		return 0
+	}
+	return len(attachments)
+}
+
+// closeFiles closes the readers of uploaded files.
+func closeFiles(files []*discordgo.File) {
+	for _, file := range files {
+		if closer, ok := file.Reader.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// formatBytes renders a size in bytes for humans.
+func formatBytes(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// deferEphemeral acknowledges an interaction with a loading state only the invoking user can see.
+func deferEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// editDeferredResponse replaces the loading state of a deferred interaction.
+func editDeferredResponse(s *discordgo.Session, i *discordgo.InteractionCreate, content string, embeds []*discordgo.MessageEmbed, files ...*discordgo.File) {
+	if embeds == nil {
+		embeds = []*discordgo.MessageEmbed{}
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+		Embeds:  &embeds,
+		Files:   files,
+	}); err != nil {
+		log.Printf("Error responding to /%s: %v", i.ApplicationCommandData().Name, err)
+	}
+}
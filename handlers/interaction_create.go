@@ -2,11 +2,12 @@ package handlers
 
 import (
 	"discord-bot/bot"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-// InteractionCreate handles slash command interactions.
+// InteractionCreate handles slash command, autocomplete and component interactions.
 func InteractionCreate(b *bot.Bot) func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	return func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		switch i.Type {
@@ -14,6 +15,17 @@ func InteractionCreate(b *bot.Bot) func(s *discordgo.Session, i *discordgo.Inter
 			CommandDispatcher(s, i)
 		case discordgo.InteractionApplicationCommandAutocomplete:
 			HandleAutocomplete(s, i)
+		case discordgo.InteractionMessageComponent:
+			HandleComponent(s, i)
 		}
 	}
 }
+
+// HandleComponent dispatches message component interactions by the prefix of their custom ID.
+func HandleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	switch {
+	case strings.HasPrefix(customID, postListPrefix+":"):
+		HandlePostListComponent(s, i)
+	}
+}
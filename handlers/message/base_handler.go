@@ -2,7 +2,10 @@ package message
 
 import (
 	"discord-bot/database/message/basedb"
+	"discord-bot/database/message/batch"
+	"discord-bot/database/message/stats"
 	"discord-bot/models"
+	"discord-bot/utils"
 	"fmt"
 	"log"
 	"slices"
@@ -13,8 +16,10 @@ import (
 
 // BaseHandler handles message events for the "base" mode.
 type BaseHandler struct {
-	db     *basedb.BaseDB
-	config models.BaseGuildConfig
+	db             *basedb.BaseDB
+	config         models.BaseGuildConfig
+	writer         *batch.Writer[models.Message] // Buffers inserts off the gateway event goroutine
+	recentMessages *utils.ExpiringSet[int64]     // Filters create events delivered twice
 }
 
 // NewBaseHandler creates a new handler for the base mode.
@@ -23,7 +28,14 @@ func NewBaseHandler(config models.BaseGuildConfig) (*BaseHandler, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize baseDB for guild %s: %w", config.GuildsID, err)
 	}
-	return &BaseHandler{db: db, config: config}, nil
+	name := "base:" + config.GuildsID
+	dedup := dedupConfig(config.Dedup)
+	return &BaseHandler{
+		db:             db,
+		config:         config,
+		writer:         batch.NewWriter(name, batch.DefaultConfig, db.SaveMessages),
+		recentMessages: newDedupSet(name+":create", dedup.CreateTTL, dedup.MaxEntries),
+	}, nil
 }
 
 // HandleCreate processes new messages for the base mode.
@@ -37,6 +49,9 @@ func (h *BaseHandler) HandleCreate(s *discordgo.Session, m *discordgo.MessageCre
 		log.Printf("BaseHandler: Error parsing IDs: %v", err)
 		return
 	}
+	if h.recentMessages.Seen(messageID) {
+		return
+	}
 
 	message := models.Message{
 		MessageID: messageID,
@@ -46,8 +61,8 @@ func (h *BaseHandler) HandleCreate(s *discordgo.Session, m *discordgo.MessageCre
 		Timestamp: time.Now().Unix(),
 	}
 
-	if err := h.db.SaveMessage(message); err != nil {
-		log.Printf("BaseHandler: Error saving message for guild %s: %v", h.config.GuildsID, err)
+	if err := h.writer.Add(message); err != nil {
+		log.Printf("BaseHandler: Error queueing message for guild %s: %v", h.config.GuildsID, err)
 	}
 }
 
@@ -61,7 +76,32 @@ func (h *BaseHandler) HandleDelete(s *discordgo.Session, m *discordgo.MessageDel
 	// Base mode does not track deletions.
 }
 
-// Close closes the database connection.
+// HandleDeleteBulk flags purged messages when enabled, so statistics are not skewed by spam.
+func (h *BaseHandler) HandleDeleteBulk(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+	if !h.config.MarkBulkDeleted || m.GuildID != h.config.GuildsID || slices.Contains(h.config.Exclude, m.ChannelID) {
+		return
+	}
+
+	// Purged messages may still be waiting in the write buffer.
+	h.writer.Flush()
+	if err := h.db.MarkBulkDeleted(m.Messages); err != nil {
+		log.Printf("BaseHandler: Error marking bulk deleted messages for guild %s: %v", h.config.GuildsID, err)
+	}
+}
+
+// GuildID returns the ID of the guild this handler records.
+func (h *BaseHandler) GuildID() string {
+	return h.config.GuildsID
+}
+
+// Stats returns the statistics query layer over the base database.
+func (h *BaseHandler) Stats() *stats.Querier {
+	return h.db.Stats()
+}
+
+// Close writes the buffered messages and closes the database connection.
 func (h *BaseHandler) Close() error {
+	h.writer.Close()
+	h.recentMessages.Close()
 	return h.db.Close()
 }
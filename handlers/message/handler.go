@@ -1,6 +1,8 @@
 package message
 
 import (
+	"discord-bot/database/message/stats"
+
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -16,6 +18,18 @@ type MessageHandler interface {
 	// HandleDelete is called when a message is deleted.
 	HandleDelete(s *discordgo.Session, m *discordgo.MessageDelete)
 
+	// HandleDeleteBulk is called when several messages are deleted at once, e.g. by a purge.
+	HandleDeleteBulk(s *discordgo.Session, m *discordgo.MessageDeleteBulk)
+
 	// Close is called to release any resources held by the handler, such as database connections.
 	Close() error
 }
+
+// StatsSource is implemented by handlers whose database can answer message statistics queries.
+type StatsSource interface {
+	// GuildID returns the ID of the guild the handler records.
+	GuildID() string
+
+	// Stats returns the statistics query layer over the handler's database.
+	Stats() *stats.Querier
+}
@@ -0,0 +1,294 @@
+package message
+
+import (
+	"discord-bot/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	modLogMaxEmbeds  = 10              // Discord allows at most 10 embeds per message
+	modLogMaxChars   = 6000            // Discord rejects messages whose embeds hold more text in total
+	modLogMaxPending = 50              // Deletions beyond this backlog are collapsed into a summary
+	modLogDelay      = 2 * time.Second // Gathers bursts into one message and spaces out sends
+	modLogFieldLimit = 1024
+	modLogEditColor  = 0xFEE75C
+	modLogDelColor   = 0xED4245
+)
+
+// modLog posts edit and deletion embeds to a guild's mod-log channel. Embeds are queued and
+// sent in batches of up to modLogMaxEmbeds embeds and modLogMaxChars characters, at most one
+// message every modLogDelay, so a bulk delete does not flood the channel or the rate limit.
+type modLog struct {
+	channelID string
+	exclude   []string
+
+	mutex    sync.Mutex
+	session  *discordgo.Session
+	pending  []*discordgo.MessageEmbed
+	overflow map[string]int // channel ID -> deletions collapsed into the summary
+	timer    *time.Timer
+	closed   bool
+}
+
+// newModLog returns the mod log of a guild, or nil when no channel is configured.
+func newModLog(config models.PlusGuildConfig) *modLog {
+	if config.ModLogChannelID == "" {
+		return nil
+	}
+	return &modLog{
+		channelID: config.ModLogChannelID,
+		exclude:   config.ModLogExclude,
+		overflow:  make(map[string]int),
+	}
+}
+
+// enabled reports whether events in channelID are posted. The mod-log channel itself is
+// always skipped so deleting log entries does not log again.
+func (l *modLog) enabled(channelID string) bool {
+	return l != nil && channelID != l.channelID && !slices.Contains(l.exclude, channelID)
+}
+
+// post queues an embed for channelID. When collapsible is set and the backlog is full,
+// the event is only counted in the summary instead of getting its own embed.
+func (l *modLog) post(s *discordgo.Session, channelID string, embed *discordgo.MessageEmbed, collapsible bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.closed {
+		return
+	}
+
+	l.session = s
+	if collapsible && len(l.pending) >= modLogMaxPending {
+		l.overflow[channelID]++
+	} else {
+		l.pending = append(l.pending, fitEmbed(embed))
+	}
+	if l.timer == nil {
+		l.timer = time.AfterFunc(modLogDelay, l.flush)
+	}
+}
+
+// flush sends the next batch and schedules another one while the backlog is not empty.
+func (l *modLog) flush() {
+	l.mutex.Lock()
+	if l.closed {
+		l.mutex.Unlock()
+		return
+	}
+	batch := l.nextBatch()
+	if len(l.pending) > 0 || len(l.overflow) > 0 {
+		l.timer = time.AfterFunc(modLogDelay, l.flush)
+	} else {
+		l.timer = nil
+	}
+	s := l.session
+	l.mutex.Unlock()
+
+	l.send(s, batch)
+}
+
+// nextBatch takes embeds off the queue while the message stays within modLogMaxEmbeds and
+// modLogMaxChars, adding the overflow summary once there is room for it. It always takes at
+// least one embed, so every call makes progress. The caller must hold the mutex.
+func (l *modLog) nextBatch() []*discordgo.MessageEmbed {
+	n, chars := 0, 0
+	for n < len(l.pending) && n < modLogMaxEmbeds {
+		length := embedLength(l.pending[n])
+		if n > 0 && chars+length > modLogMaxChars {
+			break
+		}
+		chars += length
+		n++
+	}
+	batch := l.pending[:n:n]
+	l.pending = l.pending[n:]
+	if len(batch) < modLogMaxEmbeds && len(l.overflow) > 0 {
+		if summary := overflowEmbed(l.overflow); len(batch) == 0 || chars+embedLength(summary) <= modLogMaxChars {
+			batch = append(batch, summary)
+			l.overflow = make(map[string]int)
+		}
+	}
+	return batch
+}
+
+// send posts a batch of embeds to the mod-log channel.
+func (l *modLog) send(s *discordgo.Session, batch []*discordgo.MessageEmbed) {
+	if s == nil || len(batch) == 0 {
+		return
+	}
+	if _, err := s.ChannelMessageSendEmbeds(l.channelID, batch); err != nil {
+		log.Printf("PlusHandler: Error posting to mod log channel %s: %v", l.channelID, err)
+	}
+}
+
+// close stops the timer and sends whatever is still queued.
+func (l *modLog) close() {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	l.closed = true
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	var batches [][]*discordgo.MessageEmbed
+	for len(l.pending) > 0 || len(l.overflow) > 0 {
+		batches = append(batches, l.nextBatch())
+	}
+	s := l.session
+	l.mutex.Unlock()
+
+	for _, batch := range batches {
+		l.send(s, batch)
+	}
+}
+
+// deletionEmbed describes a deleted message, using the recorded original when available.
+func deletionEmbed(m *discordgo.MessageDelete, original *models.Message) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:     "🗑️ 消息已删除",
+		Color:     modLogDelColor,
+		Footer:    &discordgo.MessageEmbedFooter{Text: "消息 ID " + m.ID},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if original == nil {
+		embed.Description = "*原消息未被记录*"
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "频道", Value: "<#" + m.ChannelID + ">", Inline: true},
+		}
+		return embed
+	}
+
+	embed.Description = modLogContent(original.MessageContent, 4096)
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{Name: "作者", Value: fmt.Sprintf("<@%d>", original.UserID), Inline: true},
+		{Name: "频道", Value: "<#" + m.ChannelID + ">", Inline: true},
+		{Name: "发送于", Value: fmt.Sprintf("<t:%d:f>", original.Timestamp), Inline: true},
+	}
+	if links := modLogAttachments(original.Attachments); links != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "附件", Value: links})
+	}
+	return embed
+}
+
+// editEmbed describes an edit with the content and attachments before and after it.
+func editEmbed(m *discordgo.MessageUpdate, authorID string, edit models.MessageEdit) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:     "✏️ 消息已编辑",
+		URL:       fmt.Sprintf("https://discord.com/channels/%s/%s/%s", m.GuildID, m.ChannelID, m.ID),
+		Color:     modLogEditColor,
+		Footer:    &discordgo.MessageEmbedFooter{Text: "消息 ID " + m.ID},
+		Timestamp: time.Unix(edit.EditTimestamp, 0).Format(time.RFC3339),
+	}
+	if authorID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "作者", Value: "<@" + authorID + ">", Inline: true})
+	}
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{Name: "频道", Value: "<#" + m.ChannelID + ">", Inline: true},
+		&discordgo.MessageEmbedField{Name: "编辑前", Value: modLogContent(edit.OriginalContent, modLogFieldLimit)},
+		&discordgo.MessageEmbedField{Name: "编辑后", Value: modLogContent(edit.EditedContent, modLogFieldLimit)},
+	)
+	if edit.OriginalAttachments != edit.EditedAttachments {
+		if links := modLogAttachments(edit.OriginalAttachments); links != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "编辑前附件", Value: links})
+		}
+		if links := modLogAttachments(edit.EditedAttachments); links != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "编辑后附件", Value: links})
+		}
+	}
+	return embed
+}
+
+// overflowEmbed summarises deletions that did not get their own embed during a burst.
+func overflowEmbed(overflow map[string]int) *discordgo.MessageEmbed {
+	channelIDs := make([]string, 0, len(overflow))
+	total := 0
+	for channelID, count := range overflow {
+		channelIDs = append(channelIDs, channelID)
+		total += count
+	}
+	slices.Sort(channelIDs)
+
+	lines := make([]string, 0, len(channelIDs))
+	for _, channelID := range channelIDs {
+		lines = append(lines, fmt.Sprintf("<#%s> — %d 条", channelID, overflow[channelID]))
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🗑️ 另有 %d 条消息被删除", total),
+		Description: modLogTruncate("短时间内删除过多，以下消息未单独记录到日志，可使用 /deleted 查看：\n"+strings.Join(lines, "\n"), 4096),
+		Color:       modLogDelColor,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+}
+
+// embedLength counts the characters Discord adds up towards modLogMaxChars.
+func embedLength(embed *discordgo.MessageEmbed) int {
+	n := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(embed.Description)
+	if embed.Footer != nil {
+		n += utf8.RuneCountInString(embed.Footer.Text)
+	}
+	if embed.Author != nil {
+		n += utf8.RuneCountInString(embed.Author.Name)
+	}
+	for _, field := range embed.Fields {
+		n += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+	return n
+}
+
+// fitEmbed shortens the description of an embed that would not fit in a message on its own.
+func fitEmbed(embed *discordgo.MessageEmbed) *discordgo.MessageEmbed {
+	if excess := embedLength(embed) - modLogMaxChars; excess > 0 {
+		embed.Description = modLogTruncate(embed.Description, max(utf8.RuneCountInString(embed.Description)-excess, 1))
+	}
+	return embed
+}
+
+// modLogContent renders message content for an embed, marking empty content explicitly.
+func modLogContent(content string, limit int) string {
+	if strings.TrimSpace(content) == "" {
+		return "*（无文字内容）*"
+	}
+	return modLogTruncate(content, limit)
+}
+
+// modLogAttachments renders the stored JSON array of attachment URLs as links that fit in one field.
+func modLogAttachments(attachments string) string {
+	if attachments == "" {
+		return ""
+	}
+	var urls []string
+	if err := json.Unmarshal([]byte(attachments), &urls); err != nil || len(urls) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for n, url := range urls {
+		link := fmt.Sprintf("[附件 %d](%s)\n", n+1, url)
+		if b.Len()+len(link) > modLogFieldLimit-20 {
+			fmt.Fprintf(&b, "… 共 %d 个附件", len(urls))
+			break
+		}
+		b.WriteString(link)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// modLogTruncate shortens s to at most limit runes, marking the cut with an ellipsis.
+func modLogTruncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,76 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// textEmbed returns an embed holding n characters of text.
+func textEmbed(n int) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{Description: strings.Repeat("字", n)}
+}
+
+func TestModLogNextBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		pending     []int // Length of each queued embed
+		overflow    bool
+		wantBatches []int // Embeds per message, the summary included
+	}{
+		{"count limit", []int{10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10}, false, []int{10, 2}},
+		{"size limit", []int{4000, 1500, 1000, 3000}, false, []int{2, 2}},
+		{"exactly the size limit", []int{3000, 3000, 1}, false, []int{2, 1}},
+		{"summary fits", []int{100, 100}, true, []int{3}},
+		{"summary waits for room", []int{3000, 2990}, true, []int{2, 1}},
+		{"summary waits for a free slot", []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, true, []int{10, 1}},
+		{"summary alone", nil, true, []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &modLog{overflow: make(map[string]int)}
+			for _, n := range tt.pending {
+				l.pending = append(l.pending, fitEmbed(textEmbed(n)))
+			}
+			if tt.overflow {
+				l.overflow["1"] = 3
+			}
+
+			var batches []int
+			for len(l.pending) > 0 || len(l.overflow) > 0 {
+				batch := l.nextBatch()
+				total := 0
+				for _, embed := range batch {
+					total += embedLength(embed)
+				}
+				if len(batch) > modLogMaxEmbeds || total > modLogMaxChars {
+					t.Fatalf("batch of %d embeds holds %d characters", len(batch), total)
+				}
+				batches = append(batches, len(batch))
+				if len(batches) > 10 {
+					t.Fatal("nextBatch made no progress")
+				}
+			}
+			if len(batches) != len(tt.wantBatches) {
+				t.Fatalf("got batches %v, want %v", batches, tt.wantBatches)
+			}
+			for i := range batches {
+				if batches[i] != tt.wantBatches[i] {
+					t.Fatalf("got batches %v, want %v", batches, tt.wantBatches)
+				}
+			}
+		})
+	}
+}
+
+func TestFitEmbed(t *testing.T) {
+	embed := textEmbed(5000)
+	embed.Fields = []*discordgo.MessageEmbedField{{Name: "附件", Value: strings.Repeat("a", modLogFieldLimit)}}
+	if n := embedLength(fitEmbed(embed)); n != modLogMaxChars {
+		t.Fatalf("fitted embed holds %d characters, want %d", n, modLogMaxChars)
+	}
+	if n := embedLength(fitEmbed(textEmbed(100))); n != 100 {
+		t.Fatalf("short embed was changed to %d characters", n)
+	}
+}
@@ -1,12 +1,20 @@
 package message
 
 import (
-	"context"
+	database "discord-bot/database/message"
+	"discord-bot/database/message/archive"
+	"discord-bot/database/message/batch"
 	"discord-bot/database/message/plusdb"
+	"discord-bot/database/message/stats"
+	"discord-bot/grpc/events"
+	eventpb "discord-bot/grpc/proto/gen/event"
 	"discord-bot/models"
+	"discord-bot/utils"
+	"encoding/json"
 	"fmt"
 	"log"
 	"slices"
+	"strconv"
 	"sync"
 	"time"
 
@@ -17,20 +25,44 @@ import (
 type PlusHandler struct {
 	db              *plusdb.PlusDB
 	config          models.PlusGuildConfig
-	ctx             context.Context    // 控制goroutine生命周期
-	cancel          context.CancelFunc // 取消函数
-	recentEdits     map[int64]time.Time
-	recentMessages  map[int64]time.Time // 防止重复消息创建
-	recentDeletions map[int64]time.Time // 防止重复删除事件
-	editsMutex      sync.RWMutex
-	messagesMutex   sync.RWMutex // 保护 recentMessages
-	deletionsMutex  sync.RWMutex // 保护 recentDeletions
-	lastCleanup     time.Time
-	lastMsgCleanup  time.Time // 消息清理时间戳
-	lastDelCleanup  time.Time // 删除清理时间戳
-	cleanupMutex    sync.Mutex
-	msgCleanupMutex sync.Mutex // 消息清理互斥锁
-	delCleanupMutex sync.Mutex // 删除清理互斥锁
+	recentEdits     *utils.ExpiringSet[int64] // 防止重复编辑事件
+	recentMessages  *utils.ExpiringSet[int64] // 防止重复消息创建
+	recentDeletions *utils.ExpiringSet[int64] // 防止重复删除事件
+	modLog          *modLog                   // nil when no mod-log channel is configured
+	archiver        *archive.Archiver         // nil when attachment archiving is disabled
+
+	// 缓冲消息写入，避免阻塞网关事件
+	writer  *batch.Writer[models.Message]
+	pending *pendingMessages // 已排队但尚未写入的消息，供编辑和删除事件查找原消息
+}
+
+// pendingMessages 保存已交给写入器但尚未写入数据库的消息。
+// 编辑和删除事件先在这里查找原消息，无需等待写入器刷新。
+type pendingMessages struct {
+	mutex    sync.Mutex
+	messages map[int64]models.Message
+}
+
+func (p *pendingMessages) add(msg models.Message) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.messages[msg.MessageID] = msg
+}
+
+func (p *pendingMessages) get(messageID int64) (models.Message, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	msg, ok := p.messages[messageID]
+	return msg, ok
+}
+
+// remove 在消息写入 (或写入失败) 后将其移出缓冲
+func (p *pendingMessages) remove(msgs []models.Message) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, msg := range msgs {
+		delete(p.messages, msg.MessageID)
+	}
 }
 
 // NewPlusHandler creates a new handler for the plus mode.
@@ -40,16 +72,37 @@ func NewPlusHandler(config models.PlusGuildConfig) (*PlusHandler, error) {
 		return nil, fmt.Errorf("failed to initialize plusDB for guild %s: %w", config.GuildsID, err)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	// 附件存档是可选功能，初始化失败时仍然记录消息
+	var archiver *archive.Archiver
+	if config.AttachmentArchive != nil {
+		archiver, err = archive.New(config.GuildsID, *config.AttachmentArchive)
+		if err != nil {
+			log.Printf("PlusHandler: Attachment archiving disabled for guild %s: %v", config.GuildsID, err)
+		} else {
+			archive.Register(config.GuildsID, archiver)
+		}
+	}
+
+	dedup := dedupConfig(config.Dedup)
+	name := "plus:" + config.GuildsID
+
+	// 消息写入数据库之后才移出缓冲，查找时总能在其中之一找到
+	pending := &pendingMessages{messages: make(map[int64]models.Message)}
+	write := func(msgs []models.Message) error {
+		defer pending.remove(msgs)
+		return db.InsertMessages(msgs)
+	}
 
 	return &PlusHandler{
 		db:              db,
 		config:          config,
-		ctx:             ctx,
-		cancel:          cancel,
-		recentEdits:     make(map[int64]time.Time),
-		recentMessages:  make(map[int64]time.Time),
-		recentDeletions: make(map[int64]time.Time),
+		recentEdits:     newDedupSet(name+":edit", dedup.EditTTL, dedup.MaxEntries),
+		recentMessages:  newDedupSet(name+":create", dedup.CreateTTL, dedup.MaxEntries),
+		recentDeletions: newDedupSet(name+":delete", dedup.DeleteTTL, dedup.MaxEntries),
+		modLog:          newModLog(config),
+		archiver:        archiver,
+		writer:          batch.NewWriter(name, batch.DefaultConfig, write),
+		pending:         pending,
 	}, nil
 }
 
@@ -65,49 +118,11 @@ func (h *PlusHandler) HandleCreate(s *discordgo.Session, m *discordgo.MessageCre
 		return
 	}
 
-	// 防止重复处理消息创建事件（检查是否在最近3秒内已处理过）
-	h.messagesMutex.RLock()
-	if lastMsg, exists := h.recentMessages[messageID]; exists && time.Since(lastMsg) < 3*time.Second {
-		h.messagesMutex.RUnlock()
+	// 防止重复处理消息创建事件
+	if h.recentMessages.Seen(messageID) {
 		log.Printf("PlusHandler: 跳过重复的消息创建事件 - 消息ID: %d", messageID)
 		return
 	}
-	h.messagesMutex.RUnlock()
-
-	// 记录这次消息处理
-	h.messagesMutex.Lock()
-	h.recentMessages[messageID] = time.Now()
-	h.messagesMutex.Unlock()
-
-	// 定期清理旧的消息记录（每60秒清理一次）
-	h.msgCleanupMutex.Lock()
-	if time.Since(h.lastMsgCleanup) > 60*time.Second {
-		go func() {
-			defer h.msgCleanupMutex.Unlock()
-
-			// 检查context是否已取消
-			select {
-			case <-h.ctx.Done():
-				return
-			default:
-			}
-
-			now := time.Now()
-
-			h.messagesMutex.Lock()
-			trimmed := make(map[int64]time.Time, len(h.recentMessages))
-			for id, timestamp := range h.recentMessages {
-				if now.Sub(timestamp) <= 30*time.Second {
-					trimmed[id] = timestamp
-				}
-			}
-			h.recentMessages = trimmed
-			h.messagesMutex.Unlock()
-			h.lastMsgCleanup = now
-		}()
-	} else {
-		h.msgCleanupMutex.Unlock()
-	}
 
 	attachmentsJSON := getAttachmentsJSON(m.Attachments)
 	message := models.Message{
@@ -120,9 +135,16 @@ func (h *PlusHandler) HandleCreate(s *discordgo.Session, m *discordgo.MessageCre
 		Attachments:    attachmentsJSON,
 		IsEdited:       false,
 	}
+	fillMetadata(s, m.Message, &message)
 
-	if err := h.db.InsertMessage(message); err != nil {
-		log.Printf("PlusHandler: Error saving message for guild %s: %v", h.config.GuildsID, err)
+	h.pending.add(message)
+	if err := h.writer.Add(message); err != nil {
+		h.pending.remove([]models.Message{message})
+		log.Printf("PlusHandler: Error queueing message for guild %s: %v", h.config.GuildsID, err)
+	}
+
+	if h.archiver != nil && len(m.Attachments) > 0 {
+		h.archiver.Enqueue(messageID, archiveSources(m.Attachments))
 	}
 }
 
@@ -139,46 +161,9 @@ func (h *PlusHandler) HandleUpdate(s *discordgo.Session, m *discordgo.MessageUpd
 	}
 
 	// Duplicate edit event prevention logic
-	h.editsMutex.RLock()
-	if lastEdit, exists := h.recentEdits[messageID]; exists && time.Since(lastEdit) < 5*time.Second {
-		h.editsMutex.RUnlock()
+	if h.recentEdits.Seen(messageID) {
 		return
 	}
-	h.editsMutex.RUnlock()
-
-	h.editsMutex.Lock()
-	h.recentEdits[messageID] = time.Now()
-	h.editsMutex.Unlock()
-
-	// Cleanup old edit records
-	h.cleanupMutex.Lock()
-	if time.Since(h.lastCleanup) > 60*time.Second {
-		go func() {
-			defer h.cleanupMutex.Unlock()
-
-			// 检查context是否已取消
-			select {
-			case <-h.ctx.Done():
-				return
-			default:
-			}
-
-			now := time.Now()
-
-			h.editsMutex.Lock()
-			trimmed := make(map[int64]time.Time, len(h.recentEdits))
-			for id, timestamp := range h.recentEdits {
-				if now.Sub(timestamp) <= 30*time.Second {
-					trimmed[id] = timestamp
-				}
-			}
-			h.recentEdits = trimmed
-			h.editsMutex.Unlock()
-			h.lastCleanup = now
-		}()
-	} else {
-		h.cleanupMutex.Unlock()
-	}
 
 	editedAttachmentsJSON := getAttachmentsJSON(m.Attachments)
 	originalContent, originalAttachments := h.getOriginalMessageContent(s, messageID, m.ChannelID, m.Content, editedAttachmentsJSON)
@@ -196,6 +181,35 @@ func (h *PlusHandler) HandleUpdate(s *discordgo.Session, m *discordgo.MessageUpd
 
 	if err := h.db.InsertMessageEdit(edit); err != nil {
 		log.Printf("PlusHandler: Error saving message edit for guild %s: %v", h.config.GuildsID, err)
+		return
+	}
+
+	// 编辑可能添加了新附件，已存档的附件会被跳过
+	if h.archiver != nil && len(m.Attachments) > 0 {
+		h.archiver.Enqueue(messageID, archiveSources(m.Attachments))
+	}
+
+	var authorID string
+	if m.Author != nil {
+		authorID = m.Author.ID
+	}
+	events.Publish(events.MessageEdited, &eventpb.MessageEditedEvent{
+		GuildId:         m.GuildID,
+		ChannelId:       m.ChannelID,
+		MessageId:       m.ID,
+		AuthorId:        authorID,
+		OriginalContent: originalContent,
+		EditedContent:   m.Content,
+		EditedAt:        edit.EditTimestamp,
+	}, map[string]string{"guild_id": m.GuildID})
+
+	if h.modLog.enabled(m.ChannelID) {
+		if authorID == "" {
+			if original, err := h.getMessage(messageID); err == nil && original != nil {
+				authorID = fmt.Sprintf("%d", original.UserID)
+			}
+		}
+		h.modLog.post(s, m.ChannelID, editEmbed(m, authorID, edit), false)
 	}
 }
 
@@ -211,49 +225,11 @@ func (h *PlusHandler) HandleDelete(s *discordgo.Session, m *discordgo.MessageDel
 		return
 	}
 
-	// 防止重复处理消息删除事件（检查是否在最近3秒内已处理过）
-	h.deletionsMutex.RLock()
-	if lastDel, exists := h.recentDeletions[messageID]; exists && time.Since(lastDel) < 3*time.Second {
-		h.deletionsMutex.RUnlock()
+	// 防止重复处理消息删除事件
+	if h.recentDeletions.Seen(messageID) {
 		log.Printf("PlusHandler: 跳过重复的消息删除事件 - 消息ID: %d", messageID)
 		return
 	}
-	h.deletionsMutex.RUnlock()
-
-	// 记录这次删除处理
-	h.deletionsMutex.Lock()
-	h.recentDeletions[messageID] = time.Now()
-	h.deletionsMutex.Unlock()
-
-	// 定期清理旧的删除记录（每60秒清理一次）
-	h.delCleanupMutex.Lock()
-	if time.Since(h.lastDelCleanup) > 60*time.Second {
-		go func() {
-			defer h.delCleanupMutex.Unlock()
-
-			// 检查context是否已取消
-			select {
-			case <-h.ctx.Done():
-				return
-			default:
-			}
-
-			now := time.Now()
-
-			h.deletionsMutex.Lock()
-			trimmed := make(map[int64]time.Time, len(h.recentDeletions))
-			for id, timestamp := range h.recentDeletions {
-				if now.Sub(timestamp) <= 30*time.Second {
-					trimmed[id] = timestamp
-				}
-			}
-			h.recentDeletions = trimmed
-			h.deletionsMutex.Unlock()
-			h.lastDelCleanup = now
-		}()
-	} else {
-		h.delCleanupMutex.Unlock()
-	}
 
 	deletion := models.MessageDeletion{
 		MessageID:         messageID,
@@ -264,7 +240,114 @@ func (h *PlusHandler) HandleDelete(s *discordgo.Session, m *discordgo.MessageDel
 
 	if err := h.db.InsertMessageDeletion(deletion); err != nil {
 		log.Printf("PlusHandler: Error saving message deletion for guild %s: %v", h.config.GuildsID, err)
+		return
 	}
+
+	event := &eventpb.MessageDeletedEvent{
+		GuildId:   m.GuildID,
+		ChannelId: m.ChannelID,
+		MessageId: m.ID,
+		DeletedAt: deletion.DeletionTimestamp,
+	}
+	// Attach the original message if we recorded it.
+	original, err := h.getMessage(messageID)
+	if err != nil {
+		log.Printf("PlusHandler: Error fetching deleted message %d: %v", messageID, err)
+	}
+	if original != nil {
+		event.AuthorId = fmt.Sprintf("%d", original.UserID)
+		event.Content = original.MessageContent
+		if original.Attachments != "" {
+			json.Unmarshal([]byte(original.Attachments), &event.Attachments)
+		}
+	}
+	events.Publish(events.MessageDeleted, event, map[string]string{"guild_id": m.GuildID})
+
+	if h.modLog.enabled(m.ChannelID) {
+		h.modLog.post(s, m.ChannelID, deletionEmbed(m, original), true)
+	}
+}
+
+// HandleDeleteBulk records every message removed by a bulk delete in one transaction.
+func (h *PlusHandler) HandleDeleteBulk(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+	if m.GuildID != h.config.GuildsID || slices.Contains(h.config.Exclude, m.ChannelID) {
+		return
+	}
+
+	guildID, err := strconv.ParseInt(m.GuildID, 10, 64)
+	if err != nil {
+		log.Printf("PlusHandler: Error parsing guild ID %s for bulk deletion: %v", m.GuildID, err)
+		return
+	}
+	channelID, err := strconv.ParseInt(m.ChannelID, 10, 64)
+	if err != nil {
+		log.Printf("PlusHandler: Error parsing channel ID %s for bulk deletion: %v", m.ChannelID, err)
+		return
+	}
+
+	now := time.Now().Unix()
+	deletions := make([]models.MessageDeletion, 0, len(m.Messages))
+	for _, id := range m.Messages {
+		messageID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			log.Printf("PlusHandler: Error parsing message ID %s for bulk deletion: %v", id, err)
+			continue
+		}
+		if h.recentDeletions.Seen(messageID) {
+			continue
+		}
+		deletions = append(deletions, models.MessageDeletion{
+			MessageID:         messageID,
+			GuildID:           guildID,
+			ChannelID:         channelID,
+			DeletionTimestamp: now,
+		})
+	}
+	if len(deletions) == 0 {
+		return
+	}
+
+	if err := h.db.InsertMessageDeletions(deletions); err != nil {
+		log.Printf("PlusHandler: Error saving bulk deletion for guild %s: %v", h.config.GuildsID, err)
+		return
+	}
+	log.Printf("PlusHandler: 记录批量删除 - 频道: %s, 消息数: %d", m.ChannelID, len(deletions))
+
+	for _, deletion := range deletions {
+		messageID := strconv.FormatInt(deletion.MessageID, 10)
+		event := &eventpb.MessageDeletedEvent{
+			GuildId:   m.GuildID,
+			ChannelId: m.ChannelID,
+			MessageId: messageID,
+			DeletedAt: deletion.DeletionTimestamp,
+		}
+		original, err := h.getMessage(deletion.MessageID)
+		if err != nil {
+			log.Printf("PlusHandler: Error fetching deleted message %d: %v", deletion.MessageID, err)
+		}
+		if original != nil {
+			event.AuthorId = fmt.Sprintf("%d", original.UserID)
+			event.Content = original.MessageContent
+			if original.Attachments != "" {
+				json.Unmarshal([]byte(original.Attachments), &event.Attachments)
+			}
+		}
+		events.Publish(events.MessageDeleted, event, map[string]string{"guild_id": m.GuildID})
+
+		if h.modLog.enabled(m.ChannelID) {
+			single := &discordgo.MessageDelete{Message: &discordgo.Message{ID: messageID, ChannelID: m.ChannelID, GuildID: m.GuildID}}
+			h.modLog.post(s, m.ChannelID, deletionEmbed(single, original), true)
+		}
+	}
+}
+
+// getMessage returns a recorded message, including one still waiting in the write buffer.
+// It returns nil, nil if the message was not recorded.
+func (h *PlusHandler) getMessage(messageID int64) (*models.Message, error) {
+	if msg, ok := h.pending.get(messageID); ok {
+		return &msg, nil
+	}
+	return h.db.GetMessage(messageID)
 }
 
 // getOriginalMessageContent tries to fetch the original content of an edited message.
@@ -278,7 +361,7 @@ func (h *PlusHandler) getOriginalMessageContent(s *discordgo.Session, messageID
 		// In this case, we need to fall back to our database.
 		if discordMessage.Content == editedContent && apiAttachmentsJSON == editedAttachments {
 			log.Printf("PlusHandler: API content matches edited content for message %d. Falling back to DB.", messageID)
-			originalMessage, dbErr := h.db.GetMessage(messageID)
+			originalMessage, dbErr := h.getMessage(messageID)
 			if dbErr == nil && originalMessage != nil {
 				return originalMessage.MessageContent, originalMessage.Attachments
 			}
@@ -297,7 +380,7 @@ func (h *PlusHandler) getOriginalMessageContent(s *discordgo.Session, messageID
 	}
 
 	// If API fails, try the database directly.
-	originalMessage, dbErr := h.db.GetMessage(messageID)
+	originalMessage, dbErr := h.getMessage(messageID)
 	if dbErr == nil && originalMessage != nil {
 		return originalMessage.MessageContent, originalMessage.Attachments
 	}
@@ -308,10 +391,64 @@ func (h *PlusHandler) getOriginalMessageContent(s *discordgo.Session, messageID
 	return "", ""
 }
 
-// Close closes the database connection and cancels all running goroutines.
+// TrackStatus registers the handler's database files under guildID with the status manager,
+// keeps db_status.json up to date when the database rotates to a new period and lets
+// cross-period queries find the files recorded there.
+func (h *PlusHandler) TrackStatus(sm *database.StatusManager, guildID string) {
+	sm.RegisterDB(guildID, "plus", h.db.CurrentPath())
+	if paths, err := h.db.PeriodPaths(); err != nil {
+		log.Printf("PlusHandler: %v", err)
+	} else {
+		sm.RegisterHistory(guildID, "plus", paths)
+	}
+	h.db.SetPathSource(func() []string {
+		return sm.DBPaths(guildID, "plus")
+	})
+
+	h.db.OnRotate(func(dbPath string) {
+		sm.RotateDB(guildID, "plus", dbPath)
+		if err := sm.Save(); err != nil {
+			log.Printf("Failed to save db_status.json: %v", err)
+		}
+	})
+}
+
+// GuildID returns the ID of the guild this handler records.
+func (h *PlusHandler) GuildID() string {
+	return h.config.GuildsID
+}
+
+// Stats returns the statistics query layer over the plus database. Queries span every period
+// file overlapping the queried range, not only the current one.
+func (h *PlusHandler) Stats() *stats.Querier {
+	return h.db.Stats()
+}
+
+// DB returns the plus database of the handler for read queries such as edit and deletion history.
+func (h *PlusHandler) DB() *plusdb.PlusDB {
+	return h.db
+}
+
+// Close flushes pending writes and logs, then closes the archive and the database connection.
 func (h *PlusHandler) Close() error {
-	// 取消所有正在运行的goroutine
-	h.cancel()
+	// 写入缓冲中的消息
+	h.writer.Close()
+
+	// 释放去重记录
+	h.recentMessages.Close()
+	h.recentEdits.Close()
+	h.recentDeletions.Close()
+
+	// 发送尚未发出的日志
+	h.modLog.close()
+
+	// 停止附件存档
+	if h.archiver != nil {
+		archive.Unregister(h.config.GuildsID, h.archiver)
+		if err := h.archiver.Close(); err != nil {
+			log.Printf("PlusHandler: Error closing attachment archive: %v", err)
+		}
+	}
 
 	// 关闭数据库连接
 	return h.db.Close()
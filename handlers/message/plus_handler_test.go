@@ -0,0 +1,46 @@
+package message
+
+import (
+	"discord-bot/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestPlusHandlerFindsBufferedMessages checks that edits and deletions can read the original of
+// a message still waiting in the write buffer, and after it has been written.
+func TestPlusHandlerFindsBufferedMessages(t *testing.T) {
+	h, err := NewPlusHandler(models.PlusGuildConfig{
+		GuildsID: "1",
+		DBPath:   filepath.Join(t.TempDir(), "messages.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewPlusHandler: %v", err)
+	}
+	defer h.Close()
+
+	now := time.Now()
+	msg := models.Message{
+		MessageID:      (now.UnixMilli() - 1420070400000) << 22,
+		GuildID:        1,
+		ChannelID:      10,
+		Timestamp:      now.Unix(),
+		MessageContent: "original",
+	}
+	h.pending.add(msg)
+	if err := h.writer.Add(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := h.getMessage(msg.MessageID); err != nil || got == nil || got.MessageContent != "original" {
+		t.Fatalf("buffered message: got %+v, %v", got, err)
+	}
+
+	h.writer.Flush()
+	if _, ok := h.pending.get(msg.MessageID); ok {
+		t.Fatal("written message is still held as pending")
+	}
+	if got, err := h.getMessage(msg.MessageID); err != nil || got == nil || got.MessageContent != "original" {
+		t.Fatalf("written message: got %+v, %v", got, err)
+	}
+}
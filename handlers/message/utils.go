@@ -1,10 +1,14 @@
 package message
 
 import (
+	"discord-bot/database/message/archive"
+	"discord-bot/models"
+	"discord-bot/utils"
 	"encoding/json"
 	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -33,6 +37,20 @@ func parseIDs(messageID, userID, guildID, channelID string) (int64, int64, int64
 	return msgID, uID, gID, cID, nil
 }
 
+// archiveSources converts message attachments into sources for the attachment archiver.
+func archiveSources(attachments []*discordgo.MessageAttachment) []archive.Source {
+	sources := make([]archive.Source, 0, len(attachments))
+	for _, attachment := range attachments {
+		sources = append(sources, archive.Source{
+			URL:         attachment.URL,
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Size:        int64(attachment.Size),
+		})
+	}
+	return sources
+}
+
 // getAttachmentsJSON is a helper function to convert attachment objects to a JSON string.
 func getAttachmentsJSON(attachments []*discordgo.MessageAttachment) string {
 	if len(attachments) == 0 {
@@ -49,3 +67,91 @@ func getAttachmentsJSON(attachments []*discordgo.MessageAttachment) string {
 	}
 	return string(jsonData)
 }
+
+// fillMetadata records replies, mentions, embeds, stickers, the message type and whether
+// the message was sent in a thread, so conversations can be reconstructed later.
+func fillMetadata(s *discordgo.Session, m *discordgo.Message, msg *models.Message) {
+	if m.Type == discordgo.MessageTypeReply && m.MessageReference != nil {
+		if replyToID, err := strconv.ParseInt(m.MessageReference.MessageID, 10, 64); err == nil {
+			msg.ReplyToID = replyToID
+		}
+	}
+
+	userIDs := make([]string, 0, len(m.Mentions))
+	for _, user := range m.Mentions {
+		userIDs = append(userIDs, user.ID)
+	}
+	msg.MentionedUsers = getIDsJSON(userIDs)
+	msg.MentionedRoles = getIDsJSON(m.MentionRoles)
+
+	stickerIDs := make([]string, 0, len(m.StickerItems))
+	for _, sticker := range m.StickerItems {
+		stickerIDs = append(stickerIDs, sticker.ID)
+	}
+	msg.StickerIDs = getIDsJSON(stickerIDs)
+
+	if len(m.Embeds) > 0 {
+		if jsonData, err := json.Marshal(m.Embeds); err != nil {
+			log.Printf("Error marshalling embeds to JSON: %v", err)
+		} else {
+			msg.Embeds = string(jsonData)
+		}
+	}
+
+	msg.MessageType = int(m.Type)
+
+	// Threads are tracked in the state cache, so this needs no API request.
+	if s != nil && s.State != nil {
+		if channel, err := s.State.Channel(m.ChannelID); err == nil {
+			msg.InThread = channel.IsThread()
+		}
+	}
+}
+
+// getIDsJSON is a helper function to convert a list of IDs to a JSON array, empty when there are none.
+func getIDsJSON(ids []string) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	jsonData, err := json.Marshal(ids)
+	if err != nil {
+		log.Printf("Error marshalling IDs to JSON: %v", err)
+		return ""
+	}
+	return string(jsonData)
+}
+
+// defaultDedup holds the dedup windows used when a guild does not configure them,
+// matching the gateway's usual redelivery delays.
+var defaultDedup = models.DedupConfig{
+	CreateTTL:  3,
+	EditTTL:    5,
+	DeleteTTL:  3,
+	MaxEntries: 10000,
+}
+
+// dedupConfig fills the unset fields of a guild's dedup config with the defaults.
+func dedupConfig(config *models.DedupConfig) models.DedupConfig {
+	resolved := defaultDedup
+	if config == nil {
+		return resolved
+	}
+	if config.CreateTTL > 0 {
+		resolved.CreateTTL = config.CreateTTL
+	}
+	if config.EditTTL > 0 {
+		resolved.EditTTL = config.EditTTL
+	}
+	if config.DeleteTTL > 0 {
+		resolved.DeleteTTL = config.DeleteTTL
+	}
+	if config.MaxEntries > 0 {
+		resolved.MaxEntries = config.MaxEntries
+	}
+	return resolved
+}
+
+// newDedupSet creates the set that filters repeated events for the same message ID.
+func newDedupSet(name string, ttlSeconds, maxEntries int) *utils.ExpiringSet[int64] {
+	return utils.NewExpiringSet[int64](name, time.Duration(ttlSeconds)*time.Second, maxEntries)
+}
@@ -1,16 +1,21 @@
 package handlers
 
 import (
+	"context"
 	"discord-bot/bot"
 	database "discord-bot/database/message"
+	"discord-bot/database/message/plusdb"
+	"discord-bot/database/message/stats"
 	"discord-bot/handlers/message"
 	"discord-bot/models"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -18,6 +23,60 @@ import (
 // MessageCollector manages and dispatches events to registered message handlers.
 type MessageCollector struct {
 	handlers []message.MessageHandler
+
+	// 关闭时拒绝新事件，并等待正在处理的事件完成
+	mutex    sync.RWMutex
+	closing  bool
+	inflight sync.WaitGroup
+	active   atomic.Int64 // 正在处理的事件数，用于关闭超时时的日志
+}
+
+// dispatch runs fn for every handler unless the collector is shutting down. The call is
+// counted as in flight so Close can wait for it before closing the databases.
+func (c *MessageCollector) dispatch(fn func(handler message.MessageHandler)) {
+	c.mutex.RLock()
+	if c.closing {
+		c.mutex.RUnlock()
+		return
+	}
+	c.inflight.Add(1)
+	c.active.Add(1)
+	c.mutex.RUnlock()
+	defer c.inflight.Done()
+	defer c.active.Add(-1)
+
+	for _, handler := range c.handlers {
+		fn(handler)
+	}
+}
+
+// drain stops dispatching new events and returns a channel that is closed once the ones in
+// flight have finished.
+func (c *MessageCollector) drain() <-chan struct{} {
+	c.mutex.Lock()
+	c.closing = true
+	c.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// closeHandlers closes all registered handlers, which writes their buffered messages and
+// closes their databases.
+func (c *MessageCollector) closeHandlers() error {
+	var errs []error
+	for _, handler := range c.handlers {
+		if err := handler.Close(); err != nil {
+			log.Printf("Error closing a handler: %v", err)
+			errs = append(errs, err)
+		}
+	}
+	log.Printf("Closed %d message handlers.", len(c.handlers))
+	return errors.Join(errs...)
 }
 
 var globalMessageCollector *MessageCollector
@@ -74,7 +133,7 @@ func InitMessageCollector(configPath string) error {
 						continue
 					}
 					collector.handlers = append(collector.handlers, handler)
-					statusManager.RegisterDB(guildID, "plus", guildConfig.DBPath)
+					handler.TrackStatus(statusManager, guildID)
 				}
 			default:
 				log.Printf("Unknown message handler mode in config: %s", mode)
@@ -97,9 +156,9 @@ func MessageCreateHandler(b *bot.Bot) func(s *discordgo.Session, m *discordgo.Me
 		if globalMessageCollector == nil {
 			return
 		}
-		for _, handler := range globalMessageCollector.handlers {
+		globalMessageCollector.dispatch(func(handler message.MessageHandler) {
 			handler.HandleCreate(s, m)
-		}
+		})
 	}
 }
 
@@ -109,14 +168,14 @@ func MessageUpdateHandler(b *bot.Bot) func(s *discordgo.Session, m *discordgo.Me
 		if globalMessageCollector == nil {
 			return
 		}
-		for _, handler := range globalMessageCollector.handlers {
+		globalMessageCollector.dispatch(func(handler message.MessageHandler) {
 			// Type assert to check if the handler implements HandleUpdate
 			if updater, ok := handler.(interface {
 				HandleUpdate(*discordgo.Session, *discordgo.MessageUpdate)
 			}); ok {
 				updater.HandleUpdate(s, m)
 			}
-		}
+		})
 	}
 }
 
@@ -126,27 +185,84 @@ func MessageDeleteHandler(b *bot.Bot) func(s *discordgo.Session, m *discordgo.Me
 		if globalMessageCollector == nil {
 			return
 		}
-		for _, handler := range globalMessageCollector.handlers {
+		globalMessageCollector.dispatch(func(handler message.MessageHandler) {
 			// Type assert to check if the handler implements HandleDelete
 			if deleter, ok := handler.(interface {
 				HandleDelete(*discordgo.Session, *discordgo.MessageDelete)
 			}); ok {
 				deleter.HandleDelete(s, m)
 			}
+		})
+	}
+}
+
+// MessageDeleteBulkHandler dispatches bulk message delete events to all handlers.
+func MessageDeleteBulkHandler(b *bot.Bot) func(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+	return func(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+		if globalMessageCollector == nil {
+			return
 		}
+		globalMessageCollector.dispatch(func(handler message.MessageHandler) {
+			handler.HandleDeleteBulk(s, m)
+		})
 	}
 }
 
-// CloseMessageCollector closes all registered handlers.
-func CloseMessageCollector() error {
+// GuildStats returns the statistics query layer for a guild. The plus database is
+// preferred over the base one because it also records message content.
+func GuildStats(guildID string) (*stats.Querier, bool) {
 	if globalMessageCollector == nil {
-		return nil
+		return nil, false
 	}
-	log.Println("Closing all message handlers...")
+	var base *stats.Querier
 	for _, handler := range globalMessageCollector.handlers {
-		if err := handler.Close(); err != nil {
-			log.Printf("Error closing a handler: %v", err)
+		source, ok := handler.(message.StatsSource)
+		if !ok || source.GuildID() != guildID {
+			continue
+		}
+		if _, isPlus := handler.(*message.PlusHandler); isPlus {
+			return source.Stats(), true
+		}
+		base = source.Stats()
+	}
+	return base, base != nil
+}
+
+// GuildHistory returns the plus database of a guild, which records message content,
+// edits and deletions. It reports false when the guild is not recorded in plus mode.
+func GuildHistory(guildID string) (*plusdb.PlusDB, bool) {
+	if globalMessageCollector == nil {
+		return nil, false
+	}
+	for _, handler := range globalMessageCollector.handlers {
+		if plus, ok := handler.(*message.PlusHandler); ok && plus.GuildID() == guildID {
+			return plus.DB(), true
 		}
 	}
-	return nil
+	return nil, false
+}
+
+// CloseMessageCollector stops dispatching message events, waits for the ones in flight and
+// closes all registered handlers. When ctx expires first, it returns without closing them,
+// since the events still running use their databases; the handlers are then closed in the
+// background once those events finish.
+func CloseMessageCollector(ctx context.Context) error {
+	c := globalMessageCollector
+	if c == nil {
+		return nil
+	}
+	log.Println("Closing all message handlers...")
+	done := c.drain()
+	select {
+	case <-done:
+		return c.closeHandlers()
+	case <-ctx.Done():
+		active := c.active.Load()
+		log.Printf("%d message events still in flight, closing the handlers once they finish", active)
+		go func() {
+			<-done
+			c.closeHandlers()
+		}()
+		return fmt.Errorf("%d message events still in flight: %w", active, ctx.Err())
+	}
 }
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"database/sql"
+	"discord-bot/database"
+	"discord-bot/models"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/spf13/viper"
+)
+
+const (
+	postListPrefix     = "post_list"
+	postListPageSize   = 5
+	postListSessionTTL = 15 * time.Minute
+	postListEmbedColor = 0x5865F2
+)
+
+// postListSession keeps the filters of a /recent_posts or /search invocation so its buttons can page through results.
+type postListSession struct {
+	guildID   string
+	userID    string
+	header    string // Shown above the embeds, followed by the page number
+	emptyText string // Shown when nothing matches
+	query     models.PostQuery
+	expiresAt time.Time
+}
+
+var (
+	postListMutex    sync.Mutex
+	postListSessions = make(map[string]*postListSession) // interaction ID -> session
+	postListDBs      = make(map[string]*sql.DB)          // guild ID -> scanning database
+)
+
+// respondPostList replies to a command with the first page of a post list and remembers the session for paging.
+func respondPostList(s *discordgo.Session, i *discordgo.InteractionCreate, session *postListSession) {
+	response, err := renderPostList(i.ID, session, 0)
+	if err != nil {
+		log.Printf("Error querying posts for guild %s: %v", session.guildID, err)
+		respondEphemeral(s, i, "🚫 查询帖子失败，请稍后重试")
+		return
+	}
+
+	postListMutex.Lock()
+	prunePostListSessions()
+	postListSessions[i.ID] = session
+	postListMutex.Unlock()
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: response,
+	})
+	if err != nil {
+		log.Printf("Error responding to /%s: %v", i.ApplicationCommandData().Name, err)
+	}
+}
+
+// HandlePostListComponent handles the pagination buttons of a post list.
+// The custom ID has the form post_list:<session id>:<page>.
+func HandlePostListComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 3 {
+		return
+	}
+	sessionID := parts[1]
+	page, err := strconv.Atoi(parts[2])
+	if err != nil || page < 0 {
+		return
+	}
+
+	postListMutex.Lock()
+	session, ok := postListSessions[sessionID]
+	if ok && time.Now().After(session.expiresAt) {
+		delete(postListSessions, sessionID)
+		ok = false
+	}
+	postListMutex.Unlock()
+
+	if !ok {
+		respondEphemeral(s, i, "⌛ 该列表已过期，请重新使用命令")
+		return
+	}
+	if session.userID != interactionUserID(i) {
+		respondEphemeral(s, i, "🚫 只有命令发起者可以翻页")
+		return
+	}
+
+	response, err := renderPostList(sessionID, session, page)
+	if err != nil {
+		log.Printf("Error querying posts for guild %s: %v", session.guildID, err)
+		respondEphemeral(s, i, "🚫 查询帖子失败，请稍后重试")
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: response,
+	})
+	if err != nil {
+		log.Printf("Error updating post list page: %v", err)
+	}
+}
+
+// renderPostList queries one page of posts and builds the message showing it.
+// Keyword queries are searched and show the matching snippet instead of the post content.
+func renderPostList(sessionID string, session *postListSession, page int) (*discordgo.InteractionResponseData, error) {
+	db, err := postListDB(session.guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := session.query
+	query.Offset = page * postListPageSize
+
+	var results []models.SearchResult
+	var total int
+	if query.Keyword != "" {
+		results, total, err = database.SearchPosts(db, query)
+	} else {
+		var posts []models.Post
+		posts, total, err = database.QueryPosts(db, query)
+		for _, post := range posts {
+			results = append(results, models.SearchResult{Post: post})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if total == 0 {
+		return &discordgo.InteractionResponseData{
+			Content:    session.emptyText,
+			Embeds:     []*discordgo.MessageEmbed{},
+			Components: []discordgo.MessageComponent{},
+		}, nil
+	}
+
+	pages := (total + postListPageSize - 1) / postListPageSize
+	if page >= pages {
+		// The result set shrank since the last page was shown, jump to the new last page.
+		return renderPostList(sessionID, session, pages-1)
+	}
+
+	embeds := make([]*discordgo.MessageEmbed, 0, len(results))
+	for _, result := range results {
+		embeds = append(embeds, postEmbed(session.guildID, result))
+	}
+
+	return &discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("%s · 第 %d/%d 页 · 共 %d 个帖子", session.header, page+1, pages, total),
+		Embeds:  embeds,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "上一页",
+						Style:    discordgo.SecondaryButton,
+						CustomID: fmt.Sprintf("%s:%s:%d", postListPrefix, sessionID, page-1),
+						Disabled: page == 0,
+					},
+					discordgo.Button{
+						Label:    "下一页",
+						Style:    discordgo.SecondaryButton,
+						CustomID: fmt.Sprintf("%s:%s:%d", postListPrefix, sessionID, page+1),
+						Disabled: page >= pages-1,
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// postEmbed renders a single post as an embed linking to the thread.
+func postEmbed(guildID string, result models.SearchResult) *discordgo.MessageEmbed {
+	post := result.Post
+	description := truncate(post.Content, 200)
+	if result.Snippet != "" {
+		description = truncate(result.Snippet, 300)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       truncate(post.Title, 256),
+		URL:         fmt.Sprintf("https://discord.com/channels/%s/%s", guildID, post.ThreadID),
+		Description: description,
+		Color:       postListEmbedColor,
+		Timestamp:   time.Unix(post.Timestamp, 0).Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "反应", Value: strconv.Itoa(post.TotalReactions), Inline: true},
+			{Name: "回复", Value: strconv.Itoa(post.MessageCount), Inline: true},
+		},
+	}
+	if post.Author != "" {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: post.Author}
+	}
+	if post.Tags != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "标签",
+			Value:  truncate(strings.ReplaceAll(post.Tags, ",", ", "), 1024),
+			Inline: true,
+		})
+	}
+	if post.CoverImageURL != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: post.CoverImageURL}
+	}
+	return embed
+}
+
+// guildScanningConfig returns the scanning config of the guild an interaction comes from,
+// replying with an error and returning false if there is none.
+func guildScanningConfig(s *discordgo.Session, i *discordgo.InteractionCreate) (models.GuildConfig, bool) {
+	var guildConfig models.GuildConfig
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🚫 该命令只能在服务器中使用")
+		return guildConfig, false
+	}
+	if err := viper.UnmarshalKey("scanning_config."+i.GuildID, &guildConfig); err != nil || guildConfig.DBPath == "" {
+		respondEphemeral(s, i, "🚫 该服务器未配置扫描数据库")
+		return guildConfig, false
+	}
+	return guildConfig, true
+}
+
+// forumChannelIDs returns the forum channels a channel option refers to: the forum itself,
+// or the forums under a category. It replies with an error and returns false if there are none.
+func forumChannelIDs(s *discordgo.Session, i *discordgo.InteractionCreate, guildConfig models.GuildConfig, channel *discordgo.Channel) ([]string, bool) {
+	if channel.Type != discordgo.ChannelTypeGuildCategory {
+		return []string{channel.ID}, true
+	}
+	channelIDs := forumChannelsInCategory(s, i.GuildID, channel.ID, guildConfig)
+	if len(channelIDs) == 0 {
+		respondEphemeral(s, i, fmt.Sprintf("🚫 分类 **%s** 下没有论坛频道", channel.Name))
+		return nil, false
+	}
+	return channelIDs, true
+}
+
+// forumChannelsInCategory returns the forum channels under a category, preferring the scanning config.
+func forumChannelsInCategory(s *discordgo.Session, guildID, categoryID string, guildConfig models.GuildConfig) []string {
+	for _, category := range guildConfig.Data {
+		if category.ID == categoryID && len(category.ChannelID) > 0 {
+			return category.ChannelID
+		}
+	}
+
+	channels, err := s.GuildChannels(guildID)
+	if err != nil {
+		log.Printf("Error fetching channels for guild %s: %v", guildID, err)
+		return nil
+	}
+	var channelIDs []string
+	for _, channel := range channels {
+		if channel.ParentID == categoryID && channel.Type == discordgo.ChannelTypeGuildForum {
+			channelIDs = append(channelIDs, channel.ID)
+		}
+	}
+	return channelIDs
+}
+
+// postListDB returns the cached scanning database connection of a guild.
+func postListDB(guildID string) (*sql.DB, error) {
+	postListMutex.Lock()
+	defer postListMutex.Unlock()
+
+	if db, ok := postListDBs[guildID]; ok {
+		return db, nil
+	}
+
+	var guildConfig models.GuildConfig
+	if err := viper.UnmarshalKey("scanning_config."+guildID, &guildConfig); err != nil {
+		return nil, err
+	}
+	if guildConfig.DBPath == "" {
+		return nil, fmt.Errorf("guild %s is not configured for scanning", guildID)
+	}
+	db, err := database.InitThreadDB(guildConfig.DBPath)
+	if err != nil {
+		return nil, err
+	}
+	postListDBs[guildID] = db
+	return db, nil
+}
+
+// prunePostListSessions drops expired sessions. The caller must hold postListMutex.
+func prunePostListSessions() {
+	now := time.Now()
+	for id, session := range postListSessions {
+		if now.After(session.expiresAt) {
+			delete(postListSessions, id)
+		}
+	}
+}
+
+// commandOptions indexes the options of a command interaction by name.
+func commandOptions(data discordgo.ApplicationCommandInteractionData) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(data.Options))
+	for _, opt := range data.Options {
+		optionMap[opt.Name] = opt
+	}
+	return optionMap
+}
+
+// resolvedChannel returns the channel an option refers to, as resolved by Discord.
+func resolvedChannel(data discordgo.ApplicationCommandInteractionData, channelID string) *discordgo.Channel {
+	if data.Resolved != nil {
+		if channel, ok := data.Resolved.Channels[channelID]; ok {
+			return channel
+		}
+	}
+	return &discordgo.Channel{ID: channelID, Name: channelID, Type: discordgo.ChannelTypeGuildForum}
+}
+
+// interactionUserID returns the ID of the user who triggered an interaction.
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// respondEphemeral replies with a message only the invoking user can see.
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// truncate shortens s to at most max runes, marking the cut with an ellipsis.
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"discord-bot/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleRecentPosts handles the logic for the /recent_posts command.
+func HandleRecentPosts(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	guildConfig, ok := guildScanningConfig(s, i)
+	if !ok {
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	optionMap := commandOptions(data)
+
+	opt, ok := optionMap["channel"]
+	if !ok {
+		respondEphemeral(s, i, "🚫 请选择一个论坛频道或分类")
+		return
+	}
+	channel := resolvedChannel(data, opt.Value.(string))
+
+	channelIDs, ok := forumChannelIDs(s, i, guildConfig, channel)
+	if !ok {
+		return
+	}
+
+	query := models.PostQuery{
+		ChannelIDs: channelIDs,
+		Status:     "active",
+		Limit:      postListPageSize,
+	}
+	if opt, ok := optionMap["tag"]; ok && strings.TrimSpace(opt.StringValue()) != "" {
+		tag := strings.TrimSpace(opt.StringValue())
+		query.Tags = []string{tag}
+		query.TagAliases = map[string][]string{tag: tagAliases(forumTags(s, channelIDs), tag)}
+	}
+	if opt, ok := optionMap["author"]; ok {
+		query.AuthorID = opt.Value.(string)
+	}
+	if opt, ok := optionMap["min_reactions"]; ok {
+		query.MinReactions = int(opt.IntValue())
+	}
+
+	respondPostList(s, i, &postListSession{
+		guildID:   i.GuildID,
+		userID:    interactionUserID(i),
+		header:    fmt.Sprintf("📰 **%s** 的最新帖子", channel.Name),
+		emptyText: fmt.Sprintf("**%s** 中没有找到符合条件的帖子。", channel.Name),
+		query:     query,
+		expiresAt: time.Now().Add(postListSessionTTL),
+	})
+}
+
+// forumTags returns the tags available in the given forum channels.
+func forumTags(s *discordgo.Session, channelIDs []string) []discordgo.ForumTag {
+	var tags []discordgo.ForumTag
+	for _, channelID := range channelIDs {
+		channel, err := s.State.Channel(channelID)
+		if err != nil {
+			if channel, err = s.Channel(channelID); err != nil {
+				log.Printf("Error fetching forum channel %s for its tags: %v", channelID, err)
+				continue
+			}
+		}
+		tags = append(tags, channel.AvailableTags...)
+	}
+	return tags
+}
+
+// tagAliases returns the other forms a tag may be stored in: the scanner stores tag IDs and
+// thread creation stores names. A tag picked from autocomplete is an ID, a typed one a name,
+// and forums under one category each have their own ID for a tag of the same name.
+func tagAliases(tags []discordgo.ForumTag, tag string) []string {
+	var name string
+	for _, t := range tags {
+		if t.ID == tag || strings.EqualFold(t.Name, tag) {
+			name = t.Name
+			break
+		}
+	}
+	if name == "" {
+		return nil
+	}
+
+	aliases := []string{name}
+	for _, t := range tags {
+		if t.Name == name && t.ID != tag {
+			aliases = append(aliases, t.ID)
+		}
+	}
+	return aliases
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"cmp"
+	"discord-bot/models"
+	"discord-bot/scanner"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	scanProgressInterval  = 10 * time.Second
+	scanProgressBarWidth  = 20
+	scanProgressMaxGuilds = 10
+	// Interaction tokens expire after 15 minutes, after which the follow-up cannot be edited anymore.
+	interactionTokenLifetime = 14 * time.Minute
+)
+
+// scanProgressReporter keeps a follow-up message of /scan start updated while the scan runs.
+type scanProgressReporter struct {
+	s           *discordgo.Session
+	interaction *discordgo.Interaction
+	messageID   string
+	deadline    time.Time
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// startScanProgress posts the progress message and starts updating it.
+func startScanProgress(s *discordgo.Session, i *discordgo.InteractionCreate) *scanProgressReporter {
+	r := &scanProgressReporter{
+		s:           s,
+		interaction: i.Interaction,
+		deadline:    time.Now().Add(interactionTokenLifetime),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	msg, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: "⏳ Starting scan...",
+	})
+	if err != nil {
+		log.Printf("Error creating scan progress message: %v", err)
+		close(r.done)
+		return r
+	}
+	r.messageID = msg.ID
+	go r.run()
+	return r
+}
+
+func (r *scanProgressReporter) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(scanProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case now := <-ticker.C:
+			if now.After(r.deadline) {
+				r.edit("⏳ The scan is still running. Use `/scan status` to follow it.")
+				return
+			}
+			// Only report a running scan, the finished one is reported by finish.
+			if progress, ok := scanner.Progress(); ok && progress.FinishedAt.IsZero() {
+				r.edit(scanProgressContent(progress))
+			}
+		}
+	}
+}
+
+// finish stops the updates and replaces the progress with content.
+func (r *scanProgressReporter) finish(content string) {
+	if r.messageID == "" {
+		r.s.FollowupMessageCreate(r.interaction, true, &discordgo.WebhookParams{Content: content})
+		return
+	}
+	close(r.stop)
+	<-r.done
+	if time.Now().After(r.deadline) {
+		log.Printf("Interaction token expired, scan result not posted: %s", content)
+		return
+	}
+	r.edit(content)
+}
+
+func (r *scanProgressReporter) edit(content string) {
+	if _, err := r.s.FollowupMessageEdit(r.interaction, r.messageID, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("Error updating scan progress message: %v", err)
+	}
+}
+
+// scanProgressContent renders the state of a scan with a progress bar, an ETA and a per-guild breakdown.
+func scanProgressContent(p models.ScanProgress) string {
+	scanType := "Active thread scan"
+	if p.FullScan {
+		scanType = "Full scan"
+	}
+	if p.DryRun {
+		scanType += " (dry run)"
+	}
+
+	var b strings.Builder
+	switch {
+	case p.FinishedAt.IsZero() && p.Cancelled:
+		fmt.Fprintf(&b, "🛑 %s `%s` is being cancelled...\n", scanType, p.ScanID)
+	case p.FinishedAt.IsZero():
+		fmt.Fprintf(&b, "⏳ %s `%s` in progress\n", scanType, p.ScanID)
+	case p.Cancelled:
+		fmt.Fprintf(&b, "🛑 %s `%s` was cancelled\n", scanType, p.ScanID)
+	default:
+		fmt.Fprintf(&b, "✅ %s `%s` completed\n", scanType, p.ScanID)
+	}
+
+	if len(p.Targets) > 0 {
+		fmt.Fprintf(&b, "Scope: %s\n", scanTargetsText(p))
+	}
+
+	percent := 0.0
+	if p.TotalPartitions > 0 {
+		percent = float64(p.PartitionsDone) / float64(p.TotalPartitions)
+	}
+	filled := int(percent * scanProgressBarWidth)
+	fmt.Fprintf(&b, "`%s%s` %d/%d channels (%.0f%%)\n",
+		strings.Repeat("█", filled), strings.Repeat("░", scanProgressBarWidth-filled),
+		p.PartitionsDone, p.TotalPartitions, percent*100)
+
+	if !p.DryRun {
+		fmt.Fprintf(&b, "Posts stored: %d · ", p.PostsFound)
+	}
+	if p.FinishedAt.IsZero() {
+		fmt.Fprintf(&b, "Elapsed %v", time.Since(p.StartedAt).Round(time.Second))
+		if p.ETA > 0 {
+			fmt.Fprintf(&b, " · ETA ~%v", p.ETA.Round(time.Second))
+		}
+	} else {
+		fmt.Fprintf(&b, "Took %v · Finished <t:%d:R>", p.FinishedAt.Sub(p.StartedAt).Round(time.Second), p.FinishedAt.Unix())
+	}
+
+	for n, guild := range p.Guilds {
+		if n == scanProgressMaxGuilds {
+			fmt.Fprintf(&b, "\n… and %d more guilds", len(p.Guilds)-n)
+			break
+		}
+		fmt.Fprintf(&b, "\n- **%s**: %d/%d channels", guild.Name, guild.PartitionsDone, guild.TotalPartitions)
+		if !p.DryRun {
+			fmt.Fprintf(&b, ", %d posts", guild.PostsFound)
+		}
+	}
+	if !p.FinishedAt.IsZero() && len(p.Targets) > 0 && p.TotalPartitions == 0 {
+		b.WriteString("\nNo configured forum channel matched the scan scope.")
+	}
+	return b.String()
+}
+
+// scanTargetsText names the channels, categories and guilds a scoped scan covers.
+func scanTargetsText(p models.ScanProgress) string {
+	names := make(map[string]string, len(p.Guilds))
+	for _, guild := range p.Guilds {
+		names[guild.GuildID] = guild.Name
+	}
+
+	parts := make([]string, 0, len(p.Targets))
+	for _, target := range p.Targets {
+		switch {
+		case target.ChannelID != "":
+			parts = append(parts, fmt.Sprintf("<#%s>", target.ChannelID))
+		case target.Key != "":
+			parts = append(parts, fmt.Sprintf("category `%s`", target.Key))
+		default:
+			parts = append(parts, fmt.Sprintf("guild **%s**", cmp.Or(names[target.GuildID], target.GuildID)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
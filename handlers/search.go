@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"discord-bot/models"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleSearch handles the logic for the /search command.
+func HandleSearch(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	guildConfig, ok := guildScanningConfig(s, i)
+	if !ok {
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	optionMap := commandOptions(data)
+
+	var keyword string
+	if opt, ok := optionMap["query"]; ok {
+		keyword = strings.TrimSpace(opt.StringValue())
+	}
+	if keyword == "" {
+		respondEphemeral(s, i, "🚫 请输入要搜索的关键词")
+		return
+	}
+
+	query := models.PostQuery{
+		Keyword: keyword,
+		Limit:   postListPageSize,
+	}
+	scope := "全部论坛"
+	if opt, ok := optionMap["channel"]; ok {
+		channel := resolvedChannel(data, opt.Value.(string))
+		channelIDs, ok := forumChannelIDs(s, i, guildConfig, channel)
+		if !ok {
+			return
+		}
+		query.ChannelIDs = channelIDs
+		scope = channel.Name
+	}
+	if opt, ok := optionMap["author"]; ok {
+		query.AuthorID = opt.Value.(string)
+	}
+
+	respondPostList(s, i, &postListSession{
+		guildID:   i.GuildID,
+		userID:    interactionUserID(i),
+		header:    fmt.Sprintf("🔍 在 **%s** 中搜索 “%s”", scope, keyword),
+		emptyText: fmt.Sprintf("在 **%s** 中没有找到与 “%s” 相关的帖子。", scope, keyword),
+		query:     query,
+		expiresAt: time.Now().Add(postListSessionTTL),
+	})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"discord-bot/database/message/stats"
+	"discord-bot/models"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	defaultStatsDays  = 7
+	defaultStatsLimit = 10
+	histogramWidth    = 20
+	statsEmbedColor   = 0x57F287
+)
+
+// HandleStats handles the logic for the /stats command family.
+func HandleStats(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🚫 该命令只能在服务器中使用")
+		return
+	}
+	querier, ok := GuildStats(i.GuildID)
+	if !ok {
+		respondEphemeral(s, i, "🚫 该服务器未启用消息记录")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		return
+	}
+	subcommand := data.Options[0]
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(subcommand.Options))
+	for _, opt := range subcommand.Options {
+		optionMap[opt.Name] = opt
+	}
+
+	days := defaultStatsDays
+	if opt, ok := optionMap["days"]; ok {
+		days = int(opt.IntValue())
+	}
+	limit := defaultStatsLimit
+	if opt, ok := optionMap["limit"]; ok {
+		limit = int(opt.IntValue())
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -days)
+	filter := models.StatsFilter{From: &from, Limit: limit}
+	if opt, ok := optionMap["channel"]; ok {
+		channelID, err := strconv.ParseInt(opt.Value.(string), 10, 64)
+		if err == nil {
+			filter.ChannelIDs = []int64{channelID}
+		}
+	}
+	if opt, ok := optionMap["user"]; ok {
+		userID, err := strconv.ParseInt(opt.Value.(string), 10, 64)
+		if err == nil {
+			filter.UserID = userID
+		}
+	}
+
+	// Queries over a busy guild can take a moment, so acknowledge first.
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	var embed *discordgo.MessageEmbed
+	var err error
+	switch subcommand.Name {
+	case "channels":
+		embed, err = channelStatsEmbed(querier, filter)
+	case "users":
+		embed, err = userStatsEmbed(querier, filter)
+	case "activity":
+		granularity := stats.Hourly
+		if opt, ok := optionMap["granularity"]; ok {
+			granularity = stats.Bucket(opt.StringValue())
+		}
+		filter.Limit = 0
+		embed, err = activityEmbed(querier, filter, granularity, now)
+	default:
+		err = fmt.Errorf("unknown subcommand %q", subcommand.Name)
+	}
+
+	content := ""
+	var embeds []*discordgo.MessageEmbed
+	if err != nil {
+		log.Printf("Error computing /stats %s for guild %s: %v", subcommand.Name, i.GuildID, err)
+		content = "🚫 统计失败，请稍后重试"
+	} else {
+		total, countErr := querier.MessageCount(models.StatsFilter{ChannelIDs: filter.ChannelIDs, UserID: filter.UserID, From: filter.From})
+		if countErr != nil {
+			log.Printf("Error counting messages for guild %s: %v", i.GuildID, countErr)
+		}
+		embed.Color = statsEmbedColor
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("最近 %d 天 · 共 %d 条消息", days, total)}
+		embed.Timestamp = now.Format(time.RFC3339)
+		embeds = []*discordgo.MessageEmbed{embed}
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+		Embeds:  &embeds,
+	}); err != nil {
+		log.Printf("Error responding to /stats: %v", err)
+	}
+}
+
+// channelStatsEmbed renders the busiest channels.
+func channelStatsEmbed(querier *stats.Querier, filter models.StatsFilter) (*discordgo.MessageEmbed, error) {
+	channelStats, err := querier.ChannelStats(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0, len(channelStats))
+	for rank, stat := range channelStats {
+		lines = append(lines, fmt.Sprintf("`%2d.` <#%d> — **%d** 条消息", rank+1, stat.ChannelID, stat.MessageCount))
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "📊 最活跃的频道",
+		Description: rankingDescription(lines),
+	}, nil
+}
+
+// userStatsEmbed renders the most active users.
+func userStatsEmbed(querier *stats.Querier, filter models.StatsFilter) (*discordgo.MessageEmbed, error) {
+	userStats, err := querier.UserStats(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0, len(userStats))
+	for rank, stat := range userStats {
+		lines = append(lines, fmt.Sprintf("`%2d.` <@%d> — **%d** 条消息", rank+1, stat.UserID, stat.MessageCount))
+	}
+	title := "📊 最活跃的用户"
+	if len(filter.ChannelIDs) == 1 {
+		title = fmt.Sprintf("📊 <#%d> 中最活跃的用户", filter.ChannelIDs[0])
+	}
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: rankingDescription(lines),
+	}, nil
+}
+
+// activityEmbed renders a text histogram of message activity. Buckets without messages are shown as zero.
+func activityEmbed(querier *stats.Querier, filter models.StatsFilter, granularity stats.Bucket, now time.Time) (*discordgo.MessageEmbed, error) {
+	activity, err := querier.Activity(filter, granularity)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(activity))
+	for _, stat := range activity {
+		counts[stat.Bucket] = stat.MessageCount
+	}
+
+	var buckets []string
+	var title string
+	switch granularity {
+	case stats.Hourly:
+		title = "🕒 每小时消息分布"
+		for hour := 0; hour < 24; hour++ {
+			buckets = append(buckets, fmt.Sprintf("%02d", hour))
+		}
+	case stats.Daily:
+		title = "📅 每日消息数量"
+		for day := filter.From.In(now.Location()); !day.After(now); day = day.AddDate(0, 0, 1) {
+			buckets = append(buckets, day.Format("2006-01-02"))
+		}
+	}
+
+	var peak int64
+	for _, bucket := range buckets {
+		peak = max(peak, counts[bucket])
+	}
+
+	var b strings.Builder
+	b.WriteString("```\n")
+	for _, bucket := range buckets {
+		count := counts[bucket]
+		width := 0
+		if peak > 0 {
+			width = int(count * histogramWidth / peak)
+		}
+		label := bucket
+		if granularity == stats.Daily {
+			label = bucket[5:] // MM-DD keeps the chart narrow
+		}
+		fmt.Fprintf(&b, "%s %-*s %d\n", label, histogramWidth, strings.Repeat("█", width), count)
+	}
+	b.WriteString("```")
+
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: b.String(),
+	}, nil
+}
+
+// rankingDescription joins ranking lines, or explains that there is nothing to show.
+func rankingDescription(lines []string) string {
+	if len(lines) == 0 {
+		return "该时间段内没有消息记录。"
+	}
+	return strings.Join(lines, "\n")
+}
@@ -2,6 +2,8 @@ package thread
 
 import (
 	"discord-bot/database"
+	"discord-bot/grpc/events"
+	eventpb "discord-bot/grpc/proto/gen/event"
 	"discord-bot/models"
 	"discord-bot/utils"
 	"fmt"
@@ -171,4 +173,15 @@ func ThreadCreateHandler(s *discordgo.Session, t *discordgo.ThreadCreate) {
 
 	log.Printf("Successfully added post for thread %s to database.", t.ID)
 	utils.Info("ThreadCreate", "AddPostToDB", fmt.Sprintf("Successfully added post for thread %s to database.", t.ID))
+
+	// 9. Notify other services through the gateway
+	events.Publish(events.PostCreated, &eventpb.PostCreatedEvent{
+		GuildId:   t.GuildID,
+		ChannelId: t.ParentID,
+		ThreadId:  t.ID,
+		AuthorId:  post.AuthorID,
+		Title:     post.Title,
+		Tags:      tagNames,
+		CreatedAt: post.Timestamp,
+	}, map[string]string{"guild_id": t.GuildID})
 }
@@ -2,10 +2,13 @@ package thread
 
 import (
 	"discord-bot/database"
+	"discord-bot/grpc/events"
+	eventpb "discord-bot/grpc/proto/gen/event"
 	"discord-bot/models"
 	"discord-bot/utils"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/spf13/viper"
@@ -42,5 +45,12 @@ func ThreadDeleteHandler(s *discordgo.Session, t *discordgo.ThreadDelete) {
 	} else {
 		details := fmt.Sprintf("Successfully marked thread %s as deleted in table %s", t.ID, tableName)
 		utils.Info("ThreadDelete", "DatabaseUpdate", details)
+
+		events.Publish(events.PostDeleted, &eventpb.PostDeletedEvent{
+			GuildId:   t.GuildID,
+			ChannelId: t.ParentID,
+			ThreadId:  t.ID,
+			DeletedAt: time.Now().Unix(),
+		}, map[string]string{"guild_id": t.GuildID})
 	}
 }
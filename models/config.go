@@ -85,9 +85,12 @@ type MessageListenerData struct {
 
 // BaseGuildConfig represents the base mode configuration for a single guild.
 type BaseGuildConfig struct {
-	GuildsID string   `json:"guilds_id" mapstructure:"guilds_id"`
-	DBPath   string   `json:"db_path" mapstructure:"db_path"`
-	Exclude  []string `json:"exclude" mapstructure:"exclude"`
+	GuildsID        string   `json:"guilds_id" mapstructure:"guilds_id"`
+	DBPath          string   `json:"db_path" mapstructure:"db_path"`
+	Exclude         []string `json:"exclude" mapstructure:"exclude"`
+	MarkBulkDeleted bool     `json:"mark_bulk_deleted,omitempty" mapstructure:"mark_bulk_deleted"` // Flag purged messages so statistics skip them
+
+	Dedup *DedupConfig `json:"dedup,omitempty" mapstructure:"dedup"` // Nil uses the default windows
 }
 
 // DBStatus represents the overall status of active databases, designed to be written to db_status.json.
@@ -104,14 +107,37 @@ type GuildDatabases struct {
 
 // DatabaseInfo contains details about a single database instance.
 type DatabaseInfo struct {
-	DBPath string `json:"db_path"`
-	Status string `json:"status"`
+	DBPath  string          `json:"db_path"`
+	Status  string          `json:"status"`
+	History []*DatabaseInfo `json:"history,omitempty"` // Files of previous periods for rotating databases
 }
 
 // PlusGuildConfig represents the plus mode configuration for a single guild.
 type PlusGuildConfig struct {
-	GuildsID string   `json:"guilds_id" mapstructure:"guilds_id"`
-	DBPath   string   `json:"db_path" mapstructure:"db_path"`
-	TimeType string   `json:"time_type" mapstructure:"time_type"`
-	Exclude  []string `json:"exclude" mapstructure:"exclude"`
+	GuildsID        string   `json:"guilds_id" mapstructure:"guilds_id"`
+	DBPath          string   `json:"db_path" mapstructure:"db_path"`
+	TimeType        string   `json:"time_type" mapstructure:"time_type"`
+	Exclude         []string `json:"exclude" mapstructure:"exclude"`
+	ModLogChannelID string   `json:"mod_log_channel_id,omitempty" mapstructure:"mod_log_channel_id"` // Channel receiving live edit/deletion logs, empty disables it
+	ModLogExclude   []string `json:"mod_log_exclude,omitempty" mapstructure:"mod_log_exclude"`       // Channels still recorded but not posted to the mod log
+
+	AttachmentArchive *AttachmentArchiveConfig `json:"attachment_archive,omitempty" mapstructure:"attachment_archive"` // Nil disables archiving
+	Dedup             *DedupConfig             `json:"dedup,omitempty" mapstructure:"dedup"`                           // Nil uses the default windows
+}
+
+// DedupConfig sets how long a message handler ignores repeated gateway events for the same
+// message. Zero values fall back to the defaults.
+type DedupConfig struct {
+	CreateTTL  int `json:"create_ttl" mapstructure:"create_ttl"`   // Seconds
+	EditTTL    int `json:"edit_ttl" mapstructure:"edit_ttl"`       // Seconds
+	DeleteTTL  int `json:"delete_ttl" mapstructure:"delete_ttl"`   // Seconds
+	MaxEntries int `json:"max_entries" mapstructure:"max_entries"` // Per event type
+}
+
+// AttachmentArchiveConfig configures the local attachment archive of a plus mode guild.
+type AttachmentArchiveConfig struct {
+	Dir          string   `json:"dir" mapstructure:"dir"`                     // Root of the content-addressed store and its index
+	MaxFileSize  int64    `json:"max_file_size" mapstructure:"max_file_size"` // Bytes, 0 means no limit
+	AllowedTypes []string `json:"allowed_types" mapstructure:"allowed_types"` // Content type prefixes such as "image/", empty allows all
+	QuotaBytes   int64    `json:"quota_bytes" mapstructure:"quota_bytes"`     // Total size of stored files, 0 means no quota
 }
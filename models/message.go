@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // MessageConfig represents the message_init.json configuration structure
 type MessageConfig struct {
 	MessageListener MessageListener `json:"message_listener" mapstructure:"message_listener"`
@@ -29,6 +31,15 @@ type Message struct {
 	MessageContent string `json:"message_content"`
 	Attachments    string `json:"attachments"` // JSON array of attachment URLs
 	IsEdited       bool   `json:"is_edited"`   // Flag indicating if the message was edited
+
+	// Metadata recorded by the plus mode to reconstruct conversations
+	ReplyToID      int64  `json:"reply_to_id"`     // ID of the message this one replies to, 0 if none
+	MentionedUsers string `json:"mentioned_users"` // JSON array of mentioned user IDs
+	MentionedRoles string `json:"mentioned_roles"` // JSON array of mentioned role IDs
+	Embeds         string `json:"embeds"`          // JSON array of the message's embeds
+	StickerIDs     string `json:"sticker_ids"`     // JSON array of sticker IDs
+	MessageType    int    `json:"message_type"`    // Discord message type, 0 for a default message
+	InThread       bool   `json:"in_thread"`       // Flag indicating if the message was sent in a thread
 }
 
 // ChannelStat represents channel message statistics
@@ -43,6 +54,31 @@ type UserStat struct {
 	MessageCount int64 `json:"message_count"`
 }
 
+// ActivityStat represents the number of messages in one bucket of an activity histogram
+type ActivityStat struct {
+	Bucket       string `json:"bucket"` // Hour of day ("00"-"23") or date ("2006-01-02")
+	MessageCount int64  `json:"message_count"`
+}
+
+// StatsFilter restricts the messages counted by a statistics query
+type StatsFilter struct {
+	ChannelIDs []int64    // Only count these channels; empty means every channel
+	UserID     int64      // Only count this user; 0 means every user
+	From       *time.Time // Inclusive lower bound, nil means unbounded
+	To         *time.Time // Exclusive upper bound, nil means unbounded
+	Limit      int        // Maximum number of rows for ranked results, 0 means unbounded
+}
+
+// MessageQuery selects recorded messages across every period database of a guild
+type MessageQuery struct {
+	UserID    int64      // Only messages of this user; 0 means every user
+	ChannelID int64      // Only messages in this channel; 0 means every channel
+	From      *time.Time // Inclusive lower bound, nil means unbounded
+	To        *time.Time // Exclusive upper bound, nil means unbounded
+	Limit     int        // Maximum number of messages, 0 means unbounded
+	Offset    int        // Number of matching messages to skip
+}
+
 // MessageDeletion represents a message deletion record
 type MessageDeletion struct {
 	DeletionID        int64 `json:"deletion_id"`        // Auto-increment ID for each deletion
@@ -52,6 +88,23 @@ type MessageDeletion struct {
 	DeletionTimestamp int64 `json:"deletion_timestamp"` // Timestamp when the deletion occurred
 }
 
+// DeletedMessage is a deletion record joined with the recorded message it removed
+type DeletedMessage struct {
+	MessageDeletion
+	Original *Message `json:"original,omitempty"` // Nil when the message was never recorded
+}
+
+// ArchivedAttachment represents an attachment copied to the local archive
+type ArchivedAttachment struct {
+	MessageID   int64  `json:"message_id"`   // ID of the message the attachment belongs to
+	URL         string `json:"url"`          // Original CDN URL, which may have expired
+	Hash        string `json:"hash"`         // SHA-256 of the content, naming the stored file
+	Filename    string `json:"filename"`     // Original file name
+	Size        int64  `json:"size"`         // Size in bytes
+	ContentType string `json:"content_type"` // MIME type
+	ArchivedAt  int64  `json:"archived_at"`  // Timestamp when the attachment was archived
+}
+
 // MessageEdit represents a message edit record
 type MessageEdit struct {
 	EditID              int64  `json:"edit_id"`              // Auto-increment ID for each edit
@@ -26,3 +26,31 @@ type Exclusion struct {
 	Reason    string `db:"reason"`
 	Timestamp int64  `db:"timestamp"`
 }
+
+// PostQuery describes the filters used when listing posts from the scanning database.
+type PostQuery struct {
+	ChannelIDs   []string // Restrict to these forum channels; empty means every channel table
+	AuthorID     string
+	Keyword      string              // Full-text search terms; results are ordered by relevance when set
+	Tags         []string            // Posts must carry all of these tags
+	TagAliases   map[string][]string // Other stored forms of a tag in Tags: scanned posts store tag IDs, created ones names
+	Status       string              // Restrict to this status; empty means any status except deleted
+	MinReactions int                 // Minimum total reactions, 0 means unbounded
+	StartTime    int64               // Unix timestamp, 0 means unbounded
+	EndTime      int64               // Unix timestamp, 0 means unbounded
+	Before       *PostCursor         // Keyset paging in newest-first order, also for keyword searches; a zero cursor starts at the newest post
+	Limit        int
+	Offset       int
+}
+
+// PostCursor is the position of a post in newest-first order, ties broken by thread ID.
+type PostCursor struct {
+	Timestamp int64
+	ThreadID  string
+}
+
+// SearchResult is a post matched by a keyword search, with a highlighted excerpt.
+type SearchResult struct {
+	Post
+	Snippet string
+}
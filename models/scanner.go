@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql"
 	"sync"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -14,9 +15,13 @@ type PartitionTask struct {
 	ChannelID          string // The specific channel to scan
 	Key                string // The category key, for context
 	IsFullScan         bool
-	PartitionsDone     *int64
-	TotalNewPostsFound *int64
+	PartitionsDone     *int64 // Counter of the partition's guild in the running scan
+	TotalNewPostsFound *int64 // Counter of the partition's guild in the running scan
 	Wg                 *sync.WaitGroup
+
+	ScanID     string // Identifies the StartScanning run in scan_runs and scan_checkpoints
+	PostsFound *int64 // Posts stored for this partition only
+	DryRun     bool   // List threads and report status changes without writing
 }
 
 // ThreadChunk is a slice of threads to be processed concurrently.
@@ -24,3 +29,82 @@ type ThreadChunk struct {
 	Threads []*discordgo.Channel
 	Index   int
 }
+
+// ScanCheckpoint records how far the archived thread pagination of an unfinished full scan
+// got in one channel, so a restarted scan can continue from there.
+type ScanCheckpoint struct {
+	GuildID   string
+	ChannelID string
+	ScanID    string    // The full scan that created the checkpoint
+	Before    time.Time // Archive timestamp of the last thread processed
+	PagesDone int
+	UpdatedAt int64
+}
+
+// Scan run statuses stored in scan_runs.
+const (
+	ScanRunRunning   = "running"
+	ScanRunCompleted = "completed"
+	ScanRunFailed    = "failed"
+	ScanRunCancelled = "cancelled"
+)
+
+// ScanRun is the history record of scanning one partition.
+type ScanRun struct {
+	ID          int64
+	ScanID      string
+	GuildID     string
+	ChannelID   string
+	FullScan    bool
+	ResumedFrom string // Scan ID of the checkpoint the run continued, empty for a fresh run
+	StartedAt   int64
+	FinishedAt  int64 // Zero while running
+	PostsFound  int64
+	Pages       int // Archived thread pages fetched
+	Status      string
+	Error       string
+}
+
+// PostReconciliation describes how a scan changes the post statuses of one channel table.
+type PostReconciliation struct {
+	GuildID   string
+	ChannelID string
+	DryRun    bool
+	Seen      int      // Threads the scan found still present
+	Archived  []string // Active posts whose threads were not seen
+	Revived   int      // Seen posts not currently active, only counted by dry runs
+	New       int      // Seen threads without a post yet, only counted by dry runs
+}
+
+// ScanProgress is a snapshot of the running scan, or of the last one once it finished.
+type ScanProgress struct {
+	ScanID          string
+	FullScan        bool
+	DryRun          bool
+	StartedAt       time.Time
+	FinishedAt      time.Time // Zero while running
+	Cancelled       bool      // Cancellation was requested
+	TotalPartitions int64     // Grows while the partitions of category entries are discovered
+	PartitionsDone  int64
+	PostsFound      int64
+	ETA             time.Duration // Estimated time left, zero until a partition finished
+	Guilds          []GuildScanProgress
+	Targets         []ScanTarget // Empty when the scan covers every configured partition
+}
+
+// GuildScanProgress is the share of one guild in a ScanProgress.
+type GuildScanProgress struct {
+	GuildID         string
+	Name            string
+	TotalPartitions int64
+	PartitionsDone  int64
+	PostsFound      int64
+}
+
+// ScanTarget limits a scan to part of a guild's configuration: the whole guild when Key and
+// ChannelID are empty, one entry of GuildConfig.Data by Key, or a single forum channel.
+type ScanTarget struct {
+	GuildID   string
+	Key       string
+	ChannelID string
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// majorParameters are the path segments whose following ID has its own rate limit.
+var majorParameters = map[string]bool{
+	"channels": true,
+	"guilds":   true,
+	"webhooks": true,
+}
+
+// tokenParents are the path segments followed by an ID and a secret token.
+var tokenParents = map[string]bool{
+	"webhooks":     true,
+	"interactions": true,
+}
+
+// routeKeys returns the route template of a request, e.g. "GET /channels/:id/messages/:id",
+// and the bucket scope, which adds the major parameter Discord tracks limits by.
+func routeKeys(req *http.Request) (route, scope string) {
+	path := strings.Split(strings.Trim(apiPath(req.URL.Path), "/"), "/")
+	segments := slices.Clone(path)
+	var major string
+	for i, segment := range path {
+		switch {
+		case i > 1 && tokenParents[path[i-2]] && isID(path[i-1]):
+			segments[i] = ":token"
+		case isID(segment):
+			if i > 0 && majorParameters[path[i-1]] && major == "" {
+				major = segment
+			}
+			segments[i] = ":id"
+		}
+	}
+	route = req.Method + " /" + strings.Join(segments, "/")
+	return route, route + "@" + major
+}
+
+// apiPath strips the "/api/v10" prefix so routes of different API versions match.
+func apiPath(path string) string {
+	rest, ok := strings.CutPrefix(path, "/api/")
+	if !ok {
+		return path
+	}
+	if _, after, found := strings.Cut(rest, "/"); found && strings.HasPrefix(rest, "v") {
+		return "/" + after
+	}
+	return "/" + rest
+}
+
+// isID reports whether a path segment is a snowflake.
+func isID(segment string) bool {
+	if len(segment) < 15 || len(segment) > 20 {
+		return false
+	}
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,397 @@
+// Package ratelimit schedules Discord REST requests around the API's rate limits.
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priority orders requests waiting for a free slot.
+type Priority int
+
+const (
+	// PriorityInteractive is used for requests without a priority, such as slash command responses.
+	PriorityInteractive Priority = iota
+	// PriorityBackground is used for bulk work such as scans.
+	PriorityBackground
+
+	priorityCount = int(PriorityBackground) + 1
+)
+
+// String returns the name used in Stats.
+func (p Priority) String() string {
+	if p == PriorityBackground {
+		return "background"
+	}
+	return "interactive"
+}
+
+type priorityKey struct{}
+
+// WithPriority marks the requests made with ctx, e.g. through discordgo.WithContext.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+func priorityOf(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return priority
+	}
+	return PriorityInteractive
+}
+
+// maxTrackedScopes bounds the bucket bookkeeping. Scans touch a new major parameter for
+// every thread, so expired entries are dropped once this many are tracked.
+const maxTrackedScopes = 10000
+
+// Config controls how many requests the Scheduler lets through.
+type Config struct {
+	MaxConcurrent      int // Requests in flight across all routes
+	InteractiveReserve int // Slots of MaxConcurrent that background requests cannot take
+	InitialRouteLimit  int // Concurrent requests per route before any response was seen
+	MaxRouteLimit      int // Upper bound the per-route limit grows to
+}
+
+// DefaultConfig matches the scanner's former global limit of 50 concurrent API calls.
+var DefaultConfig = Config{
+	MaxConcurrent:      50,
+	InteractiveReserve: 5,
+	InitialRouteLimit:  4,
+	MaxRouteLimit:      24,
+}
+
+// routeState adapts the concurrency of one route template: it grows by one request per
+// round of successful responses and halves on every 429.
+type routeState struct {
+	limit       float64
+	inFlight    int
+	rateLimited int64
+}
+
+// bucketState is the last rate limit Discord reported for a bucket and major parameter.
+type bucketState struct {
+	remaining int
+	resetAt   time.Time
+	inFlight  int // Requests started since, not yet reflected in remaining
+}
+
+// waiter is a request waiting for, or holding, a slot.
+type waiter struct {
+	route    *routeState
+	bucket   *bucketState // Known bucket the request counts against, nil before its first response
+	scope    string
+	priority Priority
+	ready    chan struct{}
+	granted  bool
+}
+
+// Scheduler is an http.RoundTripper that queues Discord REST requests by priority, limits
+// concurrency per route and holds requests back while their bucket or the global limit is
+// exhausted. discordgo still handles retries, the scheduler only decides when requests start.
+type Scheduler struct {
+	transport http.RoundTripper
+	config    Config
+
+	mutex       sync.Mutex
+	inFlight    int
+	waiting     [priorityCount]*list.List // *waiter, oldest first
+	routes      map[string]*routeState    // route template -> state
+	scopes      map[string]string         // route and major parameter -> bucket key
+	buckets     map[string]*bucketState   // bucket hash and major parameter -> state
+	globalReset time.Time
+	wake        *time.Timer
+	requests    int64
+	rateLimited int64
+}
+
+// NewScheduler wraps transport, or http.DefaultTransport when it is nil.
+func NewScheduler(transport http.RoundTripper, config Config) *Scheduler {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	s := &Scheduler{
+		transport: transport,
+		config:    config,
+		routes:    make(map[string]*routeState),
+		scopes:    make(map[string]string),
+		buckets:   make(map[string]*bucketState),
+	}
+	for i := range s.waiting {
+		s.waiting[i] = list.New()
+	}
+	return s
+}
+
+// RoundTrip waits for a slot, sends the request and learns from the response's rate limit headers.
+func (s *Scheduler) RoundTrip(req *http.Request) (*http.Response, error) {
+	route, scope := routeKeys(req)
+	w, err := s.acquire(req.Context(), route, scope, priorityOf(req.Context()))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.transport.RoundTrip(req)
+	s.release(w, resp)
+	return resp, err
+}
+
+// acquire blocks until the request may start or ctx is done.
+func (s *Scheduler) acquire(ctx context.Context, route, scope string, priority Priority) (*waiter, error) {
+	s.mutex.Lock()
+	state := s.routes[route]
+	if state == nil {
+		state = &routeState{limit: float64(s.config.InitialRouteLimit)}
+		s.routes[route] = state
+	}
+	w := &waiter{route: state, scope: scope, priority: priority, ready: make(chan struct{})}
+	if !s.queuedAhead(priority) && s.canStart(w, time.Now()) {
+		s.grant(w)
+		s.mutex.Unlock()
+		return w, nil
+	}
+	element := s.waiting[priority].PushBack(w)
+	// The requests ahead may be held back by limits that do not apply to this one.
+	s.dispatch()
+	s.mutex.Unlock()
+
+	select {
+	case <-w.ready:
+		return w, nil
+	case <-ctx.Done():
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if w.granted {
+			// Granted while giving up: hand the slot to the next request.
+			s.free(w)
+			s.dispatch()
+		} else {
+			s.waiting[priority].Remove(element)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// release frees the request's slot, adapts the route limit and wakes waiting requests.
+func (s *Scheduler) release(w *waiter, resp *http.Response) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.free(w)
+	state := w.route
+
+	if resp != nil {
+		now := time.Now()
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			state.limit = max(1, state.limit/2)
+			state.rateLimited++
+			s.rateLimited++
+			if resp.Header.Get("X-RateLimit-Global") == "true" {
+				s.globalReset = now.Add(retryAfter(resp.Header))
+			}
+		case resp.StatusCode < 400:
+			state.limit = min(float64(s.config.MaxRouteLimit), state.limit+1/state.limit)
+		}
+		s.updateBucket(w.scope, resp, now)
+	}
+	s.dispatch()
+}
+
+// updateBucket records the bucket headers of a response. The caller must hold the mutex.
+func (s *Scheduler) updateBucket(scope string, resp *http.Response, now time.Time) {
+	hash := resp.Header.Get("X-RateLimit-Bucket")
+	if hash == "" {
+		return
+	}
+	if len(s.scopes) >= maxTrackedScopes {
+		s.pruneBuckets(now)
+	}
+	key := hash + scope[strings.LastIndex(scope, "@"):]
+	s.scopes[scope] = key
+
+	bucket := s.buckets[key]
+	if bucket == nil {
+		bucket = &bucketState{}
+		s.buckets[key] = bucket
+	}
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		bucket.remaining = remaining
+	}
+	if resetAfter, err := strconv.ParseFloat(resp.Header.Get("X-RateLimit-Reset-After"), 64); err == nil {
+		bucket.resetAt = now.Add(time.Duration(resetAfter * float64(time.Second)))
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		bucket.remaining = 0
+		bucket.resetAt = now.Add(retryAfter(resp.Header))
+	}
+}
+
+// pruneBuckets forgets buckets whose limit has reset. The caller must hold the mutex.
+func (s *Scheduler) pruneBuckets(now time.Time) {
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.resetAt) {
+			delete(s.buckets, key)
+		}
+	}
+	for scope, key := range s.scopes {
+		if _, ok := s.buckets[key]; !ok {
+			delete(s.scopes, scope)
+		}
+	}
+}
+
+// queuedAhead reports whether requests of the same or a higher priority are waiting.
+// The caller must hold the mutex.
+func (s *Scheduler) queuedAhead(priority Priority) bool {
+	for p := 0; p <= int(priority); p++ {
+		if s.waiting[p].Len() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// canStart reports whether a request may start now. The caller must hold the mutex.
+func (s *Scheduler) canStart(w *waiter, now time.Time) bool {
+	if now.Before(s.globalReset) {
+		return false
+	}
+	limit := s.config.MaxConcurrent
+	if w.priority == PriorityBackground {
+		limit -= s.config.InteractiveReserve
+	}
+	if s.inFlight >= limit || w.route.inFlight >= int(w.route.limit) {
+		return false
+	}
+	if bucket := s.buckets[s.scopes[w.scope]]; bucket != nil && bucket.remaining-bucket.inFlight <= 0 && now.Before(bucket.resetAt) {
+		return false
+	}
+	return true
+}
+
+// grant starts a request. The caller must hold the mutex.
+func (s *Scheduler) grant(w *waiter) {
+	s.inFlight++
+	s.requests++
+	w.route.inFlight++
+	// Count the request against its bucket until the response reports the real value.
+	if w.bucket = s.buckets[s.scopes[w.scope]]; w.bucket != nil {
+		w.bucket.inFlight++
+	}
+	w.granted = true
+}
+
+// free gives back a granted slot. The caller must hold the mutex.
+func (s *Scheduler) free(w *waiter) {
+	s.inFlight--
+	w.route.inFlight--
+	if w.bucket != nil {
+		w.bucket.inFlight--
+	}
+}
+
+// dispatch starts every waiting request that may run, most urgent first. The caller must hold the mutex.
+func (s *Scheduler) dispatch() {
+	now := time.Now()
+	for _, queue := range s.waiting {
+		for element := queue.Front(); element != nil; {
+			next := element.Next()
+			if w := element.Value.(*waiter); s.canStart(w, now) {
+				queue.Remove(element)
+				s.grant(w)
+				close(w.ready)
+			}
+			element = next
+		}
+	}
+	s.scheduleWake(now)
+}
+
+// scheduleWake arranges a dispatch when the earliest limit blocking a waiting request resets.
+// Requests waiting for a slot are woken by release instead. The caller must hold the mutex.
+func (s *Scheduler) scheduleWake(now time.Time) {
+	var next time.Time
+	for _, queue := range s.waiting {
+		for element := queue.Front(); element != nil; element = element.Next() {
+			resetAt := s.globalReset
+			if bucket := s.buckets[s.scopes[element.Value.(*waiter).scope]]; bucket != nil && bucket.remaining-bucket.inFlight <= 0 && bucket.resetAt.After(resetAt) {
+				resetAt = bucket.resetAt
+			}
+			if resetAt.After(now) && (next.IsZero() || resetAt.Before(next)) {
+				next = resetAt
+			}
+		}
+	}
+	if next.IsZero() {
+		return
+	}
+	if s.wake != nil {
+		s.wake.Stop()
+	}
+	s.wake = time.AfterFunc(next.Sub(now), func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.dispatch()
+	})
+}
+
+// retryAfter reads the Retry-After header of a 429 response, in seconds.
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.ParseFloat(header.Get("Retry-After"), 64)
+	if err != nil || seconds <= 0 {
+		return time.Second
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// RouteStats describes the adaptive limit of one route.
+type RouteStats struct {
+	Limit       int   `json:"limit"`
+	InFlight    int   `json:"in_flight"`
+	RateLimited int64 `json:"rate_limited"`
+}
+
+// Stats is a snapshot of the scheduler's queues and counters.
+type Stats struct {
+	InFlight    int                   `json:"in_flight"`
+	Queued      map[string]int        `json:"queued"` // Waiting requests by priority
+	Requests    int64                 `json:"requests"`
+	RateLimited int64                 `json:"rate_limited"`     // 429 responses
+	Routes      map[string]RouteStats `json:"routes,omitempty"` // Routes that were rate limited or are in use
+}
+
+// QueueDepth returns the number of requests waiting for a slot.
+func (s *Scheduler) QueueDepth() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	depth := 0
+	for _, queue := range s.waiting {
+		depth += queue.Len()
+	}
+	return depth
+}
+
+// Stats returns a snapshot of the scheduler's queues and counters.
+func (s *Scheduler) Stats() Stats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stats := Stats{
+		InFlight:    s.inFlight,
+		Queued:      make(map[string]int, priorityCount),
+		Requests:    s.requests,
+		RateLimited: s.rateLimited,
+		Routes:      make(map[string]RouteStats),
+	}
+	for p, queue := range s.waiting {
+		stats.Queued[Priority(p).String()] = queue.Len()
+	}
+	for route, state := range s.routes {
+		if state.inFlight > 0 || state.rateLimited > 0 {
+			stats.Routes[route] = RouteStats{Limit: int(state.limit), InFlight: state.inFlight, RateLimited: state.rateLimited}
+		}
+	}
+	return stats
+}
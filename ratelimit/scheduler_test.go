@@ -0,0 +1,235 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	channelA = "/api/v10/channels/111111111111111111/messages"
+	channelB = "/api/v10/channels/222222222222222222/messages"
+)
+
+// fakeDiscord is a stand-in for the Discord API. Its handler decides the response of each
+// request and it records the order requests arrived in.
+type fakeDiscord struct {
+	*httptest.Server
+	mutex   sync.Mutex
+	arrived []string
+	handle  func(w http.ResponseWriter, r *http.Request, n int)
+}
+
+func newFakeDiscord(t *testing.T, handle func(w http.ResponseWriter, r *http.Request, n int)) *fakeDiscord {
+	f := &fakeDiscord{handle: handle}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mutex.Lock()
+		f.arrived = append(f.arrived, r.URL.Query().Get("id"))
+		n := len(f.arrived)
+		f.mutex.Unlock()
+		f.handle(w, r, n)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeDiscord) order() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string(nil), f.arrived...)
+}
+
+// get sends a request through the scheduler and returns its status code, or 0 on error.
+func get(t *testing.T, client *http.Client, ctx context.Context, url string) int {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// waitQueued waits until n requests are queued in s.
+func waitQueued(t *testing.T, s *Scheduler, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.QueueDepth() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue depth = %d, want %d", s.QueueDepth(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerServesInteractiveFirst(t *testing.T) {
+	unblock := make(chan struct{})
+	api := newFakeDiscord(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		if n == 1 {
+			<-unblock
+		}
+	})
+	s := NewScheduler(nil, Config{MaxConcurrent: 1, InitialRouteLimit: 1, MaxRouteLimit: 1})
+	client := &http.Client{Transport: s}
+	background := WithPriority(context.Background(), PriorityBackground)
+
+	var wg sync.WaitGroup
+	send := func(ctx context.Context, id string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			get(t, client, ctx, api.URL+channelA+"?id="+id)
+		}()
+	}
+	send(background, "blocker")
+	deadline := time.Now().Add(2 * time.Second)
+	for len(api.order()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	send(background, "bg1")
+	waitQueued(t, s, 1)
+	send(background, "bg2")
+	waitQueued(t, s, 2)
+	send(context.Background(), "interactive")
+	waitQueued(t, s, 3)
+	close(unblock)
+	wg.Wait()
+
+	want := []string{"blocker", "interactive", "bg1", "bg2"}
+	got := api.order()
+	for i := range want {
+		if i >= len(got) || got[i] != want[i] {
+			t.Fatalf("arrival order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSchedulerHonoursBucketHeaders(t *testing.T) {
+	const resetAfter = 200 * time.Millisecond
+	var first time.Time
+	api := newFakeDiscord(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		w.Header().Set("X-RateLimit-Bucket", "abcd")
+		if n == 1 {
+			first = time.Now()
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset-After", "0.2")
+			return
+		}
+		if since := time.Since(first); since < resetAfter-20*time.Millisecond {
+			t.Errorf("request %d sent %v after the bucket was exhausted, want at least %v", n, since, resetAfter)
+		}
+		w.Header().Set("X-RateLimit-Remaining", "5")
+		w.Header().Set("X-RateLimit-Reset-After", "1")
+	})
+	s := NewScheduler(nil, DefaultConfig)
+	client := &http.Client{Transport: s}
+
+	if code := get(t, client, context.Background(), api.URL+channelA); code != http.StatusOK {
+		t.Fatalf("first request status = %d", code)
+	}
+	if code := get(t, client, context.Background(), api.URL+channelA); code != http.StatusOK {
+		t.Fatalf("second request status = %d", code)
+	}
+
+}
+
+func TestSchedulerWaitsOutGlobalRateLimit(t *testing.T) {
+	const retry = 200 * time.Millisecond
+	var limitedAt time.Time
+	api := newFakeDiscord(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		if n == 1 {
+			limitedAt = time.Now()
+			w.Header().Set("X-RateLimit-Global", "true")
+			w.Header().Set("Retry-After", "0.2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if since := time.Since(limitedAt); since < retry-20*time.Millisecond {
+			t.Errorf("request sent %v after a global 429, want at least %v", since, retry)
+		}
+	})
+	s := NewScheduler(nil, DefaultConfig)
+	client := &http.Client{Transport: s}
+
+	if code := get(t, client, context.Background(), api.URL+channelA); code != http.StatusTooManyRequests {
+		t.Fatalf("first request status = %d, want 429", code)
+	}
+	// The global limit also holds back other routes.
+	if code := get(t, client, context.Background(), api.URL+"/api/v10/guilds/333333333333333333/channels"); code != http.StatusOK {
+		t.Fatalf("second request status = %d", code)
+	}
+
+	stats := s.Stats()
+	if stats.RateLimited != 1 {
+		t.Errorf("RateLimited = %d, want 1", stats.RateLimited)
+	}
+	route := stats.Routes["GET /channels/:id/messages"]
+	if route.RateLimited != 1 || route.Limit != DefaultConfig.InitialRouteLimit/2 {
+		t.Errorf("route stats = %+v, want the limit halved after one 429", route)
+	}
+}
+
+// A request must not wait behind a queued request that is blocked on a different bucket.
+func TestSchedulerSkipsRequestsBlockedOnOtherBuckets(t *testing.T) {
+	api := newFakeDiscord(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		w.Header().Set("X-RateLimit-Bucket", r.URL.Path)
+		if r.URL.Path == channelA {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset-After", "1")
+		}
+	})
+	s := NewScheduler(nil, DefaultConfig)
+	client := &http.Client{Transport: s}
+	background := WithPriority(context.Background(), PriorityBackground)
+
+	get(t, client, background, api.URL+channelA)
+
+	ctx, cancel := context.WithCancel(background)
+	defer cancel()
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		get(t, client, ctx, api.URL+channelA)
+	}()
+	waitQueued(t, s, 1)
+
+	start := time.Now()
+	if code := get(t, client, background, api.URL+channelB); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("request on a free bucket waited %v behind a blocked one", elapsed)
+	}
+
+	cancel()
+	<-blocked
+	if depth := s.QueueDepth(); depth != 0 {
+		t.Errorf("queue depth after cancel = %d, want 0", depth)
+	}
+}
+
+func TestRouteKeys(t *testing.T) {
+	tests := []struct {
+		method, path string
+		route, scope string
+	}{
+		{"GET", "/api/v10/channels/111111111111111111/messages/222222222222222222", "GET /channels/:id/messages/:id", "GET /channels/:id/messages/:id@111111111111111111"},
+		{"GET", "/api/v9/guilds/333333333333333333/channels", "GET /guilds/:id/channels", "GET /guilds/:id/channels@333333333333333333"},
+		{"POST", "/api/v10/interactions/444444444444444444/aW50ZXJhY3Rpb24/callback", "POST /interactions/:id/:token/callback", "POST /interactions/:id/:token/callback@"},
+		{"GET", "/api/v10/users/@me", "GET /users/@me", "GET /users/@me@"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		route, scope := routeKeys(req)
+		if route != tt.route || scope != tt.scope {
+			t.Errorf("routeKeys(%s %s) = %q, %q, want %q, %q", tt.method, tt.path, route, scope, tt.route, tt.scope)
+		}
+	}
+}
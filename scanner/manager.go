@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"cmp"
+	"context"
+	"discord-bot/models"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// guildCounters are the live counters of one guild in the running scan.
+type guildCounters struct {
+	name            string
+	totalPartitions int64
+	partitionsDone  int64
+	postsFound      int64
+}
+
+// scanState is the running scan's context and live counters.
+type scanState struct {
+	id        string
+	fullScan  bool
+	dryRun    bool
+	startedAt time.Time
+	targets   []models.ScanTarget
+	cancel    context.CancelFunc
+	cancelled atomic.Bool
+	done      chan struct{}             // Closed when the scan returns
+	guilds    map[string]*guildCounters // Filled before the scan starts, only the counters change afterwards
+}
+
+// snapshot reads the counters of the scan.
+func (st *scanState) snapshot(now time.Time) models.ScanProgress {
+	progress := models.ScanProgress{
+		ScanID:    st.id,
+		FullScan:  st.fullScan,
+		DryRun:    st.dryRun,
+		StartedAt: st.startedAt,
+		Cancelled: st.cancelled.Load(),
+		Targets:   st.targets,
+	}
+	for guildID, counters := range st.guilds {
+		guild := models.GuildScanProgress{
+			GuildID:         guildID,
+			Name:            counters.name,
+			TotalPartitions: atomic.LoadInt64(&counters.totalPartitions),
+			PartitionsDone:  atomic.LoadInt64(&counters.partitionsDone),
+			PostsFound:      atomic.LoadInt64(&counters.postsFound),
+		}
+		progress.TotalPartitions += guild.TotalPartitions
+		progress.PartitionsDone += guild.PartitionsDone
+		progress.PostsFound += guild.PostsFound
+		progress.Guilds = append(progress.Guilds, guild)
+	}
+	slices.SortFunc(progress.Guilds, func(a, b models.GuildScanProgress) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	if done := progress.PartitionsDone; done > 0 && done < progress.TotalPartitions {
+		perPartition := now.Sub(st.startedAt) / time.Duration(done)
+		progress.ETA = perPartition * time.Duration(progress.TotalPartitions-done)
+	}
+	return progress
+}
+
+// scanManager holds the running scan so it can be reported on and cancelled.
+type scanManager struct {
+	mutex   sync.Mutex
+	stopped bool                 // Set by Stop, no new scans start afterwards
+	current *scanState           // Nil when idle
+	last    *models.ScanProgress // Final snapshot of the last finished scan
+}
+
+var manager scanManager
+
+// begin registers a new scan, or fails if one is running or the scanner stopped.
+func (m *scanManager) begin(id string, scanningConfig models.ScanningConfig, fullScan, dryRun bool, targets []models.ScanTarget) (*scanState, context.Context, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stopped {
+		return nil, nil, ErrStopped
+	}
+	if m.current != nil {
+		return nil, nil, ErrScanInProgress
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	st := &scanState{
+		id:        id,
+		fullScan:  fullScan,
+		dryRun:    dryRun,
+		startedAt: time.Now(),
+		targets:   targets,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		guilds:    make(map[string]*guildCounters, len(scanningConfig)),
+	}
+	for guildID, guildConfig := range scanningConfig {
+		st.guilds[guildID] = &guildCounters{name: guildConfig.Name}
+	}
+	m.current = st
+	return st, ctx, nil
+}
+
+// end records the final snapshot of a scan and frees the manager for the next one.
+func (m *scanManager) end(st *scanState) {
+	now := time.Now()
+	final := st.snapshot(now)
+	final.FinishedAt = now
+	final.ETA = 0
+
+	m.mutex.Lock()
+	m.current = nil
+	m.last = &final
+	m.mutex.Unlock()
+	st.cancel()
+	close(st.done)
+}
+
+// Progress returns the running scan, or the last finished one when idle. It reports false
+// when no scan ran since the bot started.
+func Progress() (models.ScanProgress, bool) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if manager.current != nil {
+		return manager.current.snapshot(time.Now()), true
+	}
+	if manager.last != nil {
+		return *manager.last, true
+	}
+	return models.ScanProgress{}, false
+}
+
+// Cancel cancels the running scan and returns its ID without waiting for it to stop.
+// It reports false when no scan is running.
+func Cancel() (string, bool) {
+	manager.mutex.Lock()
+	st := manager.current
+	manager.mutex.Unlock()
+	if st == nil {
+		return "", false
+	}
+	if st.cancelled.CompareAndSwap(false, true) {
+		log.Printf("Cancelling scan %s...", st.id)
+		st.cancel()
+	}
+	return st.id, true
+}
+
+// Stop cancels the running scan, if any, and waits until its workers have returned or ctx
+// expires. No scan starts after Stop has been called.
+func Stop(ctx context.Context) error {
+	manager.mutex.Lock()
+	manager.stopped = true
+	st := manager.current
+	manager.mutex.Unlock()
+	if st == nil {
+		return nil
+	}
+
+	log.Println("Cancelling running scan...")
+	st.cancelled.Store(true)
+	st.cancel()
+	select {
+	case <-st.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scan did not stop in time: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"discord-bot/models"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// seenThreads collects the threads of a partition found still present during a scan.
+type seenThreads struct {
+	mutex sync.Mutex
+	ids   map[string]bool
+}
+
+func (s *seenThreads) add(threadID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ids[threadID] = true
+}
+
+func (s *seenThreads) snapshot() map[string]bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return maps.Clone(s.ids)
+}
+
+// reconcileReport collects the status changes of every partition of a scan.
+type reconcileReport struct {
+	mutex      sync.Mutex
+	partitions []models.PostReconciliation
+}
+
+func (r *reconcileReport) add(result models.PostReconciliation) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.partitions = append(r.partitions, result)
+}
+
+// summary totals the changes for the scan log.
+func (r *reconcileReport) summary() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var seen, archived, revived, added int
+	for _, p := range r.partitions {
+		seen += p.Seen
+		archived += len(p.Archived)
+		revived += p.Revived
+		added += p.New
+	}
+	return fmt.Sprintf("- 发现了 %d 个帖子\n- 将归档 %d 个帖子\n- 将恢复 %d 个帖子\n- 将新增 %d 个帖子", seen, archived, revived, added)
+}
@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"context"
+	"discord-bot/database"
+	"discord-bot/models"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// partitionRun tracks the scan_runs record of the partition a worker is scanning.
+type partitionRun struct {
+	models.ScanRun
+	recorded bool // False when the record could not be created
+}
+
+// fail marks the run as failed. The first error is kept.
+func (r *partitionRun) fail(err error) {
+	if r.Status == models.ScanRunFailed {
+		return
+	}
+	r.Status = models.ScanRunFailed
+	r.Error = err.Error()
+}
+
+// startScanRun records the start of a partition scan in scan_runs. Dry runs are not recorded.
+func startScanRun(t models.PartitionTask, checkpoint *models.ScanCheckpoint, startTime time.Time) *partitionRun {
+	run := &partitionRun{ScanRun: models.ScanRun{
+		ScanID:    t.ScanID,
+		GuildID:   t.GuildConfig.GuildsID,
+		ChannelID: t.ChannelID,
+		FullScan:  t.IsFullScan,
+		StartedAt: startTime.Unix(),
+		Status:    models.ScanRunRunning,
+	}}
+	if checkpoint != nil {
+		run.ResumedFrom = checkpoint.ScanID
+	}
+	if t.DryRun {
+		return run
+	}
+
+	id, err := database.StartScanRun(t.DB, run.ScanRun)
+	if err != nil {
+		log.Printf("Error recording scan run: %v", err)
+		return run
+	}
+	run.ID = id
+	run.recorded = true
+	return run
+}
+
+// finishScanRun stores the outcome of a partition scan. A run that neither failed nor was
+// cancelled is recorded as completed.
+func finishScanRun(ctx context.Context, t models.PartitionTask, run *partitionRun) {
+	if !run.recorded {
+		return
+	}
+	if run.Status == models.ScanRunRunning {
+		run.Status = models.ScanRunCompleted
+		if ctx.Err() != nil {
+			run.Status = models.ScanRunCancelled
+		}
+	}
+	run.FinishedAt = time.Now().Unix()
+	if t.PostsFound != nil {
+		run.PostsFound = atomic.LoadInt64(t.PostsFound)
+	}
+	if err := database.FinishScanRun(t.DB, run.ScanRun); err != nil {
+		log.Printf("Error recording scan run result: %v", err)
+	}
+}
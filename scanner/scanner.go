@@ -3,8 +3,12 @@ package scanner
 import (
 	"context"
 	"discord-bot/database"
+	"discord-bot/grpc/events"
+	eventpb "discord-bot/grpc/proto/gen/event"
 	"discord-bot/models"
+	"discord-bot/ratelimit"
 	"discord-bot/utils"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -15,42 +19,71 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+// 以下只限制扫描的 goroutine 数量，API 并发由 ratelimit.Scheduler 按路由自适应控制
 const maxPartitionConcurrency = 45          // 每个服务器内最大并发分区扫描数
 const maxThreadConcurrencyPerPartition = 24 // 每个分区内最大并发线程处理数
-const maxConcurrentAPICalls = 50            // 全局最大并发API调用数
 
-var apiSemaphore = make(chan struct{}, maxConcurrentAPICalls)
-var isScanning atomic.Bool // Add this lock
+var (
+	// ErrScanInProgress is returned when another scan is running.
+	ErrScanInProgress = errors.New("a scan is already in progress")
+	// ErrStopped is returned after Stop.
+	ErrStopped = errors.New("scanner is shutting down")
+)
 
-// StartScanning initiates the concurrent scanning process.
-func StartScanning(s *discordgo.Session, scanningConfig models.ScanningConfig, isFullScan bool) {
-	// Check if a scan is already in progress. If so, skip this run.
-	if !isScanning.CompareAndSwap(false, true) {
-		log.Println("Scanner is already running. Skipping this scan.")
-		utils.Warn("Scanner", "Concurrency", "A scan is already in progress. Skipping this scheduled scan.")
-		return
-	}
-	defer isScanning.Store(false) // Ensure the lock is released when the function exits.
+// StartScanning initiates the concurrent scanning process and returns when it finished.
+// Targets limit the scan to guilds, configured categories or forum channels of scanningConfig;
+// without targets every configured partition is scanned. Use Progress to follow the scan and
+// Cancel to stop it early.
+func StartScanning(s *discordgo.Session, scanningConfig models.ScanningConfig, isFullScan bool, targets ...models.ScanTarget) error {
+	_, err := scan(s, scanningConfig, isFullScan, false, targets)
+	return err
+}
+
+// DryRun checks the threads like StartScanning without storing their posts or writing to the
+// databases, and returns the status changes a real scan would make in each channel.
+func DryRun(s *discordgo.Session, scanningConfig models.ScanningConfig, isFullScan bool, targets ...models.ScanTarget) ([]models.PostReconciliation, error) {
+	return scan(s, scanningConfig, isFullScan, true, targets)
+}
 
+// scan runs a scan over the targeted partitions and returns the status changes of the partitions it reconciled.
+func scan(s *discordgo.Session, scanningConfig models.ScanningConfig, isFullScan, dryRun bool, targets []models.ScanTarget) ([]models.PostReconciliation, error) {
 	startTime := time.Now()
 	scanType := "partial"
 	if isFullScan {
 		scanType = "full"
 	}
-	utils.Info("Scanner", "Scan Start", fmt.Sprintf("Starting a %s scan.", scanType))
+	scanID := fmt.Sprintf("%s-%d", scanType, startTime.Unix())
+	scanningConfig, scopes := scopeTargets(scanningConfig, targets)
+
+	// Only one scan runs at a time. If one is in progress, skip this run.
+	state, ctx, err := manager.begin(scanID, scanningConfig, isFullScan, dryRun, targets)
+	switch {
+	case errors.Is(err, ErrScanInProgress):
+		log.Println("Scanner is already running. Skipping this scan.")
+		utils.Warn("Scanner", "Concurrency", "A scan is already in progress. Skipping this scheduled scan.")
+		return nil, err
+	case err != nil:
+		log.Println("Scanner is shutting down. Skipping this scan.")
+		return nil, err
+	}
+	defer manager.end(state)
+
+	if dryRun {
+		scanType += " dry-run"
+	}
+	if len(targets) > 0 {
+		utils.Info("Scanner", "Scan Start", fmt.Sprintf("Starting a %s scan of %d targets.", scanType, len(targets)))
+	} else {
+		utils.Info("Scanner", "Scan Start", fmt.Sprintf("Starting a %s scan.", scanType))
+	}
 
 	if len(scanningConfig) == 0 {
 		utils.Warn("Scanner", "Configuration", "No valid guild configurations found.")
-		return
+		return nil, nil
 	}
 
 	var wg sync.WaitGroup
-	var totalPartitions int64
-	var partitionsDone int64
-	var totalNewPostsFound int64
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	report := &reconcileReport{}
 
 	taskChan := make(chan models.PartitionTask, maxPartitionConcurrency)
 	workerWg := &sync.WaitGroup{}
@@ -59,13 +92,15 @@ func StartScanning(s *discordgo.Session, scanningConfig models.ScanningConfig, i
 	numWorkers := maxPartitionConcurrency
 	workerWg.Add(numWorkers)
 	for i := 0; i < numWorkers; i++ {
-		go worker(s, ctx, taskChan, workerWg)
+		go worker(s, ctx, taskChan, workerWg, report)
 	}
 
 	for guildID, guildConfig := range scanningConfig {
 		wg.Add(1)
 		go func(guildID string, guildConfig models.GuildConfig) {
 			defer wg.Done()
+			counters := state.guilds[guildID]
+			scope := scopes[guildID]
 
 			log.Printf("Preparing to scan guild: %s (%s)", guildConfig.Name, guildID)
 			db, err := database.InitDB(guildConfig.DBPath)
@@ -75,10 +110,23 @@ func StartScanning(s *discordgo.Session, scanningConfig models.ScanningConfig, i
 			}
 			defer db.Close()
 
+			// Only one scan runs at a time, so runs still marked as running were interrupted.
+			if !dryRun {
+				if err := database.InterruptScanRuns(db, guildID); err != nil {
+					log.Printf("Guild %s: %v", guildID, err)
+				}
+			}
+
 			var partitionWg sync.WaitGroup
 			for key, channelConfig := range guildConfig.Data {
+				if !scope.coversKey(key) {
+					continue
+				}
 				processChannel := func(chID string) {
-					atomic.AddInt64(&totalPartitions, 1)
+					if !scope.covers(key, chID) {
+						return
+					}
+					atomic.AddInt64(&counters.totalPartitions, 1)
 					partitionWg.Add(1)
 					task := models.PartitionTask{
 						DB:                 db,
@@ -86,9 +134,11 @@ func StartScanning(s *discordgo.Session, scanningConfig models.ScanningConfig, i
 						ChannelID:          chID,
 						Key:                key,
 						IsFullScan:         isFullScan,
-						PartitionsDone:     &partitionsDone,
-						TotalNewPostsFound: &totalNewPostsFound,
+						PartitionsDone:     &counters.partitionsDone,
+						TotalNewPostsFound: &counters.postsFound,
 						Wg:                 &partitionWg,
+						ScanID:             scanID,
+						DryRun:             dryRun,
 					}
 					taskChan <- task
 				}
@@ -98,7 +148,7 @@ func StartScanning(s *discordgo.Session, scanningConfig models.ScanningConfig, i
 						processChannel(chID)
 					}
 				} else {
-					channels, err := s.GuildChannels(guildID)
+					channels, err := s.GuildChannels(guildID, backgroundRequest(ctx))
 					if err != nil {
 						log.Printf("Failed to get channels for guild %s: %v", guildID, err)
 						return
@@ -119,9 +169,25 @@ func StartScanning(s *discordgo.Session, scanningConfig models.ScanningConfig, i
 	workerWg.Wait()
 
 	duration := time.Since(startTime)
-	totalFound := atomic.LoadInt64(&totalNewPostsFound)
+	if ctx.Err() != nil {
+		utils.Warn("Scanner", "Scan Cancelled", fmt.Sprintf("The %s scan was cancelled after %v.", scanType, duration))
+		return report.partitions, ctx.Err()
+	}
+	progress := state.snapshot(time.Now())
+	totalFound := progress.PostsFound
 	guildsScanned := len(scanningConfig)
-	finalPartitions := atomic.LoadInt64(&totalPartitions)
+	finalPartitions := progress.TotalPartitions
+	if len(targets) > 0 && finalPartitions == 0 {
+		utils.Warn("Scanner", "Scope", "No configured forum channel matched the scan targets.")
+	}
+
+	if dryRun {
+		details := fmt.Sprintf("预演扫描完成\n- 扫描了 %d 个服务器\n- 一共执行了 %d 个频道\n%s\n- 耗时 %v",
+			guildsScanned, finalPartitions, report.summary(), duration)
+		utils.Info("Scanner", "Dry Run Finish", details)
+		log.Print(details)
+		return report.partitions, nil
+	}
 
 	details := fmt.Sprintf(
 		"扫描完成\n- 扫描了 %d 个服务器\n- 一共执行了 %d 个频道\n- 发现了 %d 个帖子\n- 耗时 %v",
@@ -132,10 +198,25 @@ func StartScanning(s *discordgo.Session, scanningConfig models.ScanningConfig, i
 	)
 	utils.Info("Scanner", "Scan Finish", details)
 	log.Print(details)
+
+	events.Publish(events.ScanFinished, &eventpb.ScanFinishedEvent{
+		FullScan:      isFullScan,
+		GuildsScanned: int32(guildsScanned),
+		Partitions:    finalPartitions,
+		PostsFound:    totalFound,
+		DurationMs:    duration.Milliseconds(),
+		FinishedAt:    time.Now().Unix(),
+	}, map[string]string{"scan_type": scanType})
+	return report.partitions, nil
+}
+
+// backgroundRequest queues a scan's API call behind interactive requests and aborts it when the scan is cancelled.
+func backgroundRequest(ctx context.Context) discordgo.RequestOption {
+	return discordgo.WithContext(ratelimit.WithPriority(ctx, ratelimit.PriorityBackground))
 }
 
 // worker is the core processing unit in the pool.
-func worker(s *discordgo.Session, ctx context.Context, tasks <-chan models.PartitionTask, wg *sync.WaitGroup) {
+func worker(s *discordgo.Session, ctx context.Context, tasks <-chan models.PartitionTask, wg *sync.WaitGroup, report *reconcileReport) {
 	defer wg.Done()
 	for task := range tasks {
 		// Process each task in its own function scope to ensure defer is called correctly.
@@ -143,24 +224,43 @@ func worker(s *discordgo.Session, ctx context.Context, tasks <-chan models.Parti
 			if t.Wg != nil {
 				defer t.Wg.Done()
 			}
+			// Every way out of the partition counts towards the scan's progress.
+			defer atomic.AddInt64(t.PartitionsDone, 1)
+			// Drain the remaining tasks without touching the API once the scan is cancelled.
+			if ctx.Err() != nil {
+				return
+			}
 			startTime := time.Now()
 			channelID := t.ChannelID
 			tableName := "channel_" + channelID
-
-			if err := database.CreateTableForChannel(t.DB, tableName); err != nil {
-				log.Printf("Error creating table %s: %v", tableName, err)
-				atomic.AddInt64(t.PartitionsDone, 1)
-				return // Use return instead of continue
+			var postsFound int64
+			t.PostsFound = &postsFound
+
+			// A full scan continues where an interrupted one stopped paginating archived threads.
+			var checkpoint *models.ScanCheckpoint
+			if t.IsFullScan && !t.DryRun {
+				var err error
+				if checkpoint, err = database.GetScanCheckpoint(t.DB, t.GuildConfig.GuildsID, channelID); err != nil {
+					log.Printf("Error loading scan checkpoint, scanning channel %s from the start: %v", channelID, err)
+				}
 			}
-
-			// Phase 1: Archive all posts in the channel
-			log.Printf("Phase 1: Archiving all posts in channel %s", channelID)
-			if err := database.ArchiveAllPosts(t.DB, tableName); err != nil {
-				log.Printf("Error archiving all posts in table %s: %v", tableName, err)
-				atomic.AddInt64(t.PartitionsDone, 1)
-				return
+			run := startScanRun(t, checkpoint, startTime)
+			defer finishScanRun(ctx, t, run)
+
+			if !t.DryRun {
+				if err := database.CreateTableForChannel(t.DB, tableName); err != nil {
+					log.Printf("Error creating table %s: %v", tableName, err)
+					run.fail(err)
+					return // Use return instead of continue
+				}
+			}
+			if checkpoint != nil {
+				log.Printf("Resuming full scan %s of channel %s after %d pages", checkpoint.ScanID, channelID, checkpoint.PagesDone)
 			}
 
+			// Threads found still present. Posts of the other threads are archived once the scan succeeds.
+			seen := &seenThreads{ids: make(map[string]bool)}
+
 			existingThreads := make(map[string]bool)
 			existingThreadsMutex := &sync.RWMutex{}
 
@@ -195,27 +295,35 @@ func worker(s *discordgo.Session, ctx context.Context, tasks <-chan models.Parti
 						defer chunkWg.Done()
 						semaphore <- struct{}{}
 						defer func() { <-semaphore }()
-						processThreadsChunk(s, c, existingThreads, existingThreadsMutex, t, tableName, ctx)
+						processThreadsChunk(s, c, existingThreads, existingThreadsMutex, seen, t, tableName, ctx)
 					}(chunk)
 				}
 				chunkWg.Wait()
 			}
 
-			// Phase 2: Scan and update active threads
-			log.Printf("Phase 2: Scanning active threads for channel %s", channelID)
-			activeThreads, err := s.ThreadsActive(channelID)
+			// Phase 1: Scan and update active threads
+			log.Printf("Phase 1: Scanning active threads for channel %s", channelID)
+			activeThreads, err := s.ThreadsActive(channelID, backgroundRequest(ctx))
 			if err != nil {
 				log.Printf("Error getting active threads for channel %s: %v", channelID, err)
-				atomic.AddInt64(t.PartitionsDone, 1)
+				run.fail(err)
 				return // Use return instead of continue
 			}
 			processThreadsConcurrently(activeThreads.Threads, "active")
 
+			completed := true
 			if t.IsFullScan {
-				// Phase 3: Process archived threads (full scan only)
-				log.Printf("Phase 3: Processing archived threads for channel %s (full scan)", channelID)
+				// Phase 2: Process archived threads (full scan only)
+				log.Printf("Phase 2: Processing archived threads for channel %s (full scan)", channelID)
 				var before *time.Time
 				pageCount := 0
+				scanOriginID := t.ScanID
+				if checkpoint != nil {
+					before = &checkpoint.Before
+					pageCount = checkpoint.PagesDone
+					scanOriginID = checkpoint.ScanID
+				}
+				completed = false
 				for {
 					pageCount++
 					select {
@@ -225,16 +333,19 @@ func worker(s *discordgo.Session, ctx context.Context, tasks <-chan models.Parti
 					default:
 					}
 
-					archivedThreads, err := s.ThreadsArchived(channelID, before, 100)
+					archivedThreads, err := s.ThreadsArchived(channelID, before, 100, backgroundRequest(ctx))
 					if err != nil {
 						log.Printf("Error getting archived threads for channel %s on page %d: %v", channelID, pageCount, err)
+						run.fail(err)
 						break
 					}
+					run.Pages++
 
 					log.Printf("Page %d: Fetched %d archived threads for channel %s. HasMore: %v", pageCount, len(archivedThreads.Threads), channelID, archivedThreads.HasMore)
 
 					if len(archivedThreads.Threads) == 0 {
 						log.Printf("No more archived threads found for channel %s on page %d.", channelID, pageCount)
+						completed = true
 						break
 					}
 
@@ -242,29 +353,68 @@ func worker(s *discordgo.Session, ctx context.Context, tasks <-chan models.Parti
 
 					if !archivedThreads.HasMore {
 						log.Printf("Stopping archived thread fetch for channel %s: HasMore is false.", channelID)
+						completed = true
 						break
 					}
 
 					lastThread := archivedThreads.Threads[len(archivedThreads.Threads)-1]
 					if lastThread.ThreadMetadata == nil {
 						log.Printf("Archived thread %s has no metadata, stopping pagination.", lastThread.ID)
+						completed = true
 						break
 					}
 					before = &lastThread.ThreadMetadata.ArchiveTimestamp
+
+					// Only record pages whose threads were all processed.
+					if ctx.Err() == nil && !t.DryRun {
+						if err := database.SaveScanCheckpoint(t.DB, models.ScanCheckpoint{
+							GuildID:   t.GuildConfig.GuildsID,
+							ChannelID: channelID,
+							ScanID:    scanOriginID,
+							Before:    *before,
+							PagesDone: pageCount,
+						}); err != nil {
+							log.Printf("Error saving scan checkpoint: %v", err)
+						}
+					}
+				}
+
+				// Keep the checkpoint of a failed or cancelled pagination so the next full scan resumes it.
+				if completed && ctx.Err() == nil && !t.DryRun {
+					if err := database.DeleteScanCheckpoint(t.DB, t.GuildConfig.GuildsID, channelID); err != nil {
+						log.Printf("Error deleting scan checkpoint: %v", err)
+					}
+				}
+			}
+
+			// Phase 3: Archive the posts of threads that were not seen. This only happens when every
+			// thread was listed, so a failed or cancelled scan never archives posts that still exist.
+			// A resumed scan did not see the threads of the pages before its checkpoint.
+			switch {
+			case !completed || ctx.Err() != nil:
+				log.Printf("Skipping status reconciliation of channel %s: the scan did not finish", channelID)
+			case checkpoint != nil:
+				log.Printf("Skipping status reconciliation of channel %s: the scan was resumed", channelID)
+			default:
+				result, err := database.ReconcilePostStatus(t.DB, tableName, seen.snapshot(), startTime, t.DryRun)
+				if err != nil {
+					log.Printf("Error reconciling post status for channel %s: %v", channelID, err)
+					run.fail(err)
+				} else {
+					result.GuildID = t.GuildConfig.GuildsID
+					result.ChannelID = channelID
+					report.add(*result)
 				}
 			}
+
 			log.Printf("Partition %s (%s) scan completed in %v", t.Key, t.GuildConfig.Name, time.Since(startTime))
-			atomic.AddInt64(t.PartitionsDone, 1)
 		}(task)
 	}
 }
 
-func processThreadsChunk(s *discordgo.Session, chunk models.ThreadChunk, existingThreads map[string]bool, existingThreadsMutex *sync.RWMutex, task models.PartitionTask, tableName string, ctx context.Context) {
+func processThreadsChunk(s *discordgo.Session, chunk models.ThreadChunk, existingThreads map[string]bool, existingThreadsMutex *sync.RWMutex, seen *seenThreads, task models.PartitionTask, tableName string, ctx context.Context) {
 	for _, thread := range chunk.Threads {
-		apiSemaphore <- struct{}{} // Acquire API semaphore
 		func() {
-			defer func() { <-apiSemaphore }() // Release API semaphore
-
 			select {
 			case <-ctx.Done():
 				return
@@ -283,18 +433,28 @@ func processThreadsChunk(s *discordgo.Session, chunk models.ThreadChunk, existin
 				return // Skip excluded threads only
 			}
 
-			firstMessage, err := s.ChannelMessage(thread.ID, thread.ID)
+			// A dry run fetches the first message too, so threads a real scan would exclude are not counted as seen.
+			firstMessage, err := s.ChannelMessage(thread.ID, thread.ID, backgroundRequest(ctx))
 			if err != nil {
 				if restErr, ok := err.(*discordgo.RESTError); ok && restErr.Response.StatusCode == 404 {
+					if task.DryRun {
+						return
+					}
 					log.Printf("Thread %s not found (404), adding to exclusion list.", thread.ID)
 					if err := database.AddThreadToExclusionList(task.DB, task.GuildConfig.GuildsID, task.ChannelID, thread.ID, "Not Found"); err != nil {
 						log.Printf("Error adding thread %s to exclusion list: %v", thread.ID, err)
 					}
 				} else {
+					// The thread still exists, keep its post as it is.
 					log.Printf("Error getting first message for thread %s: %v", thread.ID, err)
+					seen.add(thread.ID)
 				}
 				return
 			}
+			seen.add(thread.ID)
+			if task.DryRun {
+				return
+			}
 
 			var tagNames []string
 			if thread.AppliedTags != nil {
@@ -319,7 +479,7 @@ func processThreadsChunk(s *discordgo.Session, chunk models.ThreadChunk, existin
 
 			for _, reaction := range firstMessage.Reactions {
 				totalReactions += reaction.Count
-				users, err := s.MessageReactions(thread.ID, firstMessage.ID, reaction.Emoji.APIName(), 100, "", "")
+				users, err := s.MessageReactions(thread.ID, firstMessage.ID, reaction.Emoji.APIName(), 100, "", "", backgroundRequest(ctx))
 				if err != nil {
 					log.Printf("Error getting users for reaction %s on message %s: %v", reaction.Emoji.APIName(), firstMessage.ID, err)
 					continue
@@ -349,6 +509,9 @@ func processThreadsChunk(s *discordgo.Session, chunk models.ThreadChunk, existin
 				log.Printf("Error upserting active post %s into database: %v", post.ThreadID, err)
 			} else {
 				atomic.AddInt64(task.TotalNewPostsFound, 1)
+				if task.PostsFound != nil {
+					atomic.AddInt64(task.PostsFound, 1)
+				}
 				existingThreadsMutex.Lock()
 				existingThreads[post.ThreadID] = true
 				existingThreadsMutex.Unlock()
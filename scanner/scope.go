@@ -0,0 +1,58 @@
+package scanner
+
+import (
+	"discord-bot/models"
+	"log"
+)
+
+// guildScope is the part of a guild's configuration a scoped scan covers.
+type guildScope struct {
+	all      bool            // The whole guild was targeted
+	keys     map[string]bool // Targeted entries of GuildConfig.Data
+	channels map[string]bool // Targeted forum channels
+}
+
+// scopeTargets reduces the scanning configuration to the guilds the targets name and returns
+// the scope of each. Without targets everything is scanned and the scopes are nil.
+func scopeTargets(scanningConfig models.ScanningConfig, targets []models.ScanTarget) (models.ScanningConfig, map[string]*guildScope) {
+	if len(targets) == 0 {
+		return scanningConfig, nil
+	}
+
+	scoped := make(models.ScanningConfig)
+	scopes := make(map[string]*guildScope)
+	for _, target := range targets {
+		guildConfig, ok := scanningConfig[target.GuildID]
+		if !ok {
+			log.Printf("Scan target guild %s is not configured, skipping it.", target.GuildID)
+			continue
+		}
+		scoped[target.GuildID] = guildConfig
+
+		scope := scopes[target.GuildID]
+		if scope == nil {
+			scope = &guildScope{keys: make(map[string]bool), channels: make(map[string]bool)}
+			scopes[target.GuildID] = scope
+		}
+		switch {
+		case target.ChannelID != "":
+			scope.channels[target.ChannelID] = true
+		case target.Key != "":
+			scope.keys[target.Key] = true
+		default:
+			scope.all = true
+		}
+	}
+	return scoped, scopes
+}
+
+// coversKey reports whether any partition of the entry may be in scope, so entries that cannot
+// match are skipped without listing the guild's channels. A nil scope covers everything.
+func (sc *guildScope) coversKey(key string) bool {
+	return sc == nil || sc.all || sc.keys[key] || len(sc.channels) > 0
+}
+
+// covers reports whether the partition of channelID under the entry key is in scope.
+func (sc *guildScope) covers(key, channelID string) bool {
+	return sc == nil || sc.all || sc.keys[key] || sc.channels[channelID]
+}
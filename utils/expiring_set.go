@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// ExpiringSetStats is a snapshot of an ExpiringSet's counters.
+type ExpiringSetStats struct {
+	Size    int   `json:"size"`
+	Hits    int64 `json:"hits"`    // Keys seen again within the TTL
+	Misses  int64 `json:"misses"`  // Keys seen for the first time or after they expired
+	Evicted int64 `json:"evicted"` // Unexpired keys dropped because the set was full
+}
+
+type expiringEntry[K comparable] struct {
+	key  K
+	seen time.Time
+}
+
+// ExpiringSet remembers keys for a fixed TTL and holds at most maxSize of them, dropping
+// the oldest when full. Expired keys are removed while the set is used, so it needs no
+// cleanup goroutine.
+type ExpiringSet[K comparable] struct {
+	name    string
+	ttl     time.Duration
+	maxSize int
+	now     func() time.Time // Replaced in tests
+
+	mutex   sync.Mutex
+	order   *list.List // Entries from oldest to newest
+	entries map[K]*list.Element
+	hits    int64
+	misses  int64
+	evicted int64
+}
+
+// NewExpiringSet creates a set named name and lists it in AllExpiringSetStats until Close.
+// A maxSize of 0 or less leaves the set unbounded.
+func NewExpiringSet[K comparable](name string, ttl time.Duration, maxSize int) *ExpiringSet[K] {
+	set := &ExpiringSet[K]{
+		name:    name,
+		ttl:     ttl,
+		maxSize: maxSize,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[K]*list.Element),
+	}
+	registerExpiringSet(name, set)
+	return set
+}
+
+// Seen reports whether key was added within the TTL. If it was not, key is added and
+// false is returned, so the first caller of a duplicated event handles it.
+func (s *ExpiringSet[K]) Seen(key K) bool {
+	now := s.now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pruneExpired(now)
+
+	if _, ok := s.entries[key]; ok {
+		s.hits++
+		return true
+	}
+	s.misses++
+
+	if s.maxSize > 0 {
+		for s.order.Len() >= s.maxSize {
+			s.remove(s.order.Front())
+			s.evicted++
+		}
+	}
+	s.entries[key] = s.order.PushBack(&expiringEntry[K]{key: key, seen: now})
+	return false
+}
+
+// Stats returns a snapshot of the set's counters.
+func (s *ExpiringSet[K]) Stats() ExpiringSetStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pruneExpired(s.now())
+	return ExpiringSetStats{
+		Size:    s.order.Len(),
+		Hits:    s.hits,
+		Misses:  s.misses,
+		Evicted: s.evicted,
+	}
+}
+
+// Close forgets every key and removes the set from AllExpiringSetStats.
+func (s *ExpiringSet[K]) Close() {
+	unregisterExpiringSet(s.name, s)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.order.Init()
+	clear(s.entries)
+}
+
+// pruneExpired removes entries older than the TTL. Entries are kept in the order they
+// were added, so it stops at the first one still alive. The caller must hold the mutex.
+func (s *ExpiringSet[K]) pruneExpired(now time.Time) {
+	for front := s.order.Front(); front != nil; front = s.order.Front() {
+		if now.Sub(front.Value.(*expiringEntry[K]).seen) < s.ttl {
+			return
+		}
+		s.remove(front)
+	}
+}
+
+// remove deletes an entry. The caller must hold the mutex.
+func (s *ExpiringSet[K]) remove(element *list.Element) {
+	delete(s.entries, element.Value.(*expiringEntry[K]).key)
+	s.order.Remove(element)
+}
+
+// statsSource is implemented by every ExpiringSet regardless of its key type.
+type statsSource interface {
+	Stats() ExpiringSetStats
+}
+
+var (
+	expiringSetsMutex sync.RWMutex
+	expiringSets      = make(map[string]statsSource) // set name -> set
+)
+
+func registerExpiringSet(name string, set statsSource) {
+	expiringSetsMutex.Lock()
+	defer expiringSetsMutex.Unlock()
+	expiringSets[name] = set
+}
+
+func unregisterExpiringSet(name string, set statsSource) {
+	expiringSetsMutex.Lock()
+	defer expiringSetsMutex.Unlock()
+	if expiringSets[name] == set {
+		delete(expiringSets, name)
+	}
+}
+
+// AllExpiringSetStats returns the counters of every open set, keyed by name.
+func AllExpiringSetStats() map[string]ExpiringSetStats {
+	expiringSetsMutex.RLock()
+	defer expiringSetsMutex.RUnlock()
+	stats := make(map[string]ExpiringSetStats, len(expiringSets))
+	for name, set := range expiringSets {
+		stats[name] = set.Stats()
+	}
+	return stats
+}
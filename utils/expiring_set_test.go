@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for ExpiringSet.now.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestExpiringSet(t *testing.T) {
+	const ttl = time.Minute
+
+	// step advances the clock by after, then checks Seen(key).
+	type step struct {
+		after    time.Duration
+		key      string
+		wantSeen bool
+	}
+	tests := []struct {
+		name      string
+		maxSize   int
+		steps     []step
+		wantStats ExpiringSetStats
+	}{
+		{
+			name:      "repeat within the TTL",
+			steps:     []step{{0, "a", false}, {ttl / 2, "a", true}, {0, "b", false}},
+			wantStats: ExpiringSetStats{Size: 2, Hits: 1, Misses: 2},
+		},
+		{
+			name:      "expired key is new again",
+			steps:     []step{{0, "a", false}, {ttl, "a", false}},
+			wantStats: ExpiringSetStats{Size: 1, Misses: 2},
+		},
+		{
+			name:      "a hit does not extend the TTL",
+			steps:     []step{{0, "a", false}, {ttl - time.Second, "a", true}, {time.Second, "a", false}},
+			wantStats: ExpiringSetStats{Size: 1, Hits: 1, Misses: 2},
+		},
+		{
+			name:      "oldest key evicted when full",
+			maxSize:   2,
+			steps:     []step{{0, "a", false}, {0, "b", false}, {0, "c", false}, {0, "b", true}, {0, "a", false}},
+			wantStats: ExpiringSetStats{Size: 2, Hits: 1, Misses: 4, Evicted: 2},
+		},
+		{
+			name:      "expired keys make room without eviction",
+			maxSize:   2,
+			steps:     []step{{0, "a", false}, {0, "b", false}, {ttl, "c", false}, {0, "d", false}},
+			wantStats: ExpiringSetStats{Size: 2, Misses: 4},
+		},
+		{
+			name:      "unbounded",
+			steps:     []step{{0, "a", false}, {0, "b", false}, {0, "c", false}, {0, "a", true}},
+			wantStats: ExpiringSetStats{Size: 3, Hits: 1, Misses: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(1_000_000, 0)}
+			set := NewExpiringSet[string]("test "+tt.name, ttl, tt.maxSize)
+			set.now = clock.Now
+			defer set.Close()
+
+			for i, s := range tt.steps {
+				clock.now = clock.now.Add(s.after)
+				if seen := set.Seen(s.key); seen != s.wantSeen {
+					t.Fatalf("step %d: Seen(%q) = %v, want %v", i, s.key, seen, s.wantSeen)
+				}
+			}
+			if stats := set.Stats(); stats != tt.wantStats {
+				t.Fatalf("got stats %+v, want %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestExpiringSetStatsExpireWithoutUse(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_000_000, 0)}
+	set := NewExpiringSet[int]("test idle", time.Minute, 0)
+	set.now = clock.Now
+	defer set.Close()
+
+	set.Seen(1)
+	set.Seen(2)
+	clock.now = clock.now.Add(time.Minute)
+	if stats := set.Stats(); stats.Size != 0 {
+		t.Fatalf("got size %d after the TTL, want 0", stats.Size)
+	}
+}
+
+func TestExpiringSetClose(t *testing.T) {
+	const name = "test close"
+	set := NewExpiringSet[string](name, time.Minute, 0)
+	set.Seen("a")
+	set.Seen("a")
+
+	stats, ok := AllExpiringSetStats()[name]
+	if !ok {
+		t.Fatalf("%q missing from AllExpiringSetStats", name)
+	}
+	if stats.Size != 1 || stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("got stats %+v", stats)
+	}
+
+	// A set replacing another of the same name stays listed when the old one closes.
+	replacement := NewExpiringSet[string](name, time.Minute, 0)
+	set.Close()
+	if stats, ok := AllExpiringSetStats()[name]; !ok || stats.Misses != 0 {
+		t.Fatalf("replacement set not listed after closing the old one: %+v, %v", stats, ok)
+	}
+
+	replacement.Close()
+	if _, ok := AllExpiringSetStats()[name]; ok {
+		t.Fatalf("%q still listed after Close", name)
+	}
+	if set.Seen("a") {
+		t.Fatal("closed set still remembers its keys")
+	}
+}